@@ -79,7 +79,7 @@ for target in "${!BUILD_TARGETS[@]}"; do
         -trimpath \
         -tags 'netgo osusergo' \
         -o "$OUTPUT_NAME" \
-        translation_service.go
+        .
     
     if [ -f "$OUTPUT_NAME" ]; then
         # Get file size
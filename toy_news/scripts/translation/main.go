@@ -0,0 +1,9 @@
+// Command translation-service translates the pending toy products queued by
+// the scrapers. The service itself lives in the translation package.
+package main
+
+import "translation-service/translation"
+
+func main() {
+	translation.Main()
+}
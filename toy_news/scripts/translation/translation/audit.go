@@ -0,0 +1,99 @@
+package translation
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AuditEntry represents a stored translation that disagrees with the cache
+type AuditEntry struct {
+	ProductHash string
+	Field       string
+	Source      string
+	Stored      string
+	Cached      string // empty when the source text isn't cached
+}
+
+// AuditConsistency samples up to limit translated normalized products and
+// compares their stored translations with the cached translations of their
+// source texts. It returns the mismatches and how many translations were
+// checked. Nothing is written.
+func (ts *TranslationService) AuditConsistency(ctx context.Context, limit int) ([]AuditEntry, int, error) {
+	var targetFilters []bson.M
+	for _, field := range ts.fieldsToTranslate {
+		targetFilters = append(targetFilters, bson.M{ts.targetKey(field): bson.M{"$nin": bson.A{nil, ""}}})
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"$or": targetFilters}}},
+		{{Key: "$sample", Value: bson.M{"size": limit}}},
+	}
+	cursor, err := ts.normalizedCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error sampling normalized items: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var items []NormalizedItem
+	err = cursor.All(ctx, &items)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error decoding normalized items: %w", err)
+	}
+
+	var entries []AuditEntry
+	checked := 0
+	for _, item := range items {
+		for _, field := range ts.fieldsToTranslate {
+			source, stored := ts.itemField(item, field)
+			if source == "" || stored == "" {
+				continue
+			}
+			checked++
+
+			// The cache holds the translation of the text without boilerplate
+			text, a := ts.stripBoilerplate(field, source)
+			cached, err := ts.GetCachedTranslation(ctx, field, text)
+			if err != nil {
+				return nil, checked, err
+			}
+			if cached != "" {
+				cached = a.wrap(cached)
+			}
+			if cached != stored {
+				entries = append(entries, AuditEntry{
+					ProductHash: item.ProductHash,
+					Field:       field,
+					Source:      source,
+					Stored:      stored,
+					Cached:      cached,
+				})
+			}
+		}
+	}
+	return entries, checked, nil
+}
+
+// PrintAuditReport prints the audit mismatches followed by a summary
+func PrintAuditReport(entries []AuditEntry, checked int) {
+	uncached := 0
+	for _, entry := range entries {
+		status := "mismatch"
+		if entry.Cached == "" {
+			status = "uncached"
+			uncached++
+		}
+
+		fmt.Printf("[%s] %s %s\n", status, entry.ProductHash, entry.Field)
+		fmt.Printf("  原文: %s\n", entry.Source)
+		fmt.Printf("  stored: %s\n", entry.Stored)
+		if entry.Cached != "" {
+			fmt.Printf("  cached: %s\n", entry.Cached)
+		}
+	}
+
+	fmt.Printf("Audit summary: %d translations checked, %d mismatched, %d not cached\n",
+		checked, len(entries)-uncached, uncached)
+}
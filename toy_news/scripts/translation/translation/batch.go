@@ -0,0 +1,200 @@
+package translation
+
+import (
+	"context"
+	"errors"
+	"log"
+)
+
+// subBatch is a slice of a batch sent in one API call. start is the index of
+// its first text in the batch, so results land back in input order no matter
+// in which order the calls complete.
+type subBatch struct {
+	start int
+	texts []string
+}
+
+// splitBatch cuts texts into consecutive sub-batches of at most size texts
+func splitBatch(texts []string, size int) []subBatch {
+	var batches []subBatch
+	for start := 0; start < len(texts); start += size {
+		end := start + size
+		if end > len(texts) {
+			end = len(texts)
+		}
+		batches = append(batches, subBatch{start: start, texts: texts[start:end]})
+	}
+	return batches
+}
+
+// packBatch cuts texts into consecutive sub-batches whose estimated tokens
+// stay within budget, so short texts share a call and long ones get smaller
+// calls. A text over the budget on its own gets a call of its own. maxTexts
+// additionally caps the texts per sub-batch when positive.
+func packBatch(texts []string, budget, maxTexts int) []subBatch {
+	var batches []subBatch
+	start, tokens := 0, 0
+	for i, text := range texts {
+		cost := estimateTokens(text)
+		full := maxTexts > 0 && i-start >= maxTexts
+		if i > start && (tokens+cost > budget || full) {
+			batches = append(batches, subBatch{start: start, texts: texts[start:i]})
+			start, tokens = i, 0
+		}
+		tokens += cost
+	}
+	if start < len(texts) {
+		batches = append(batches, subBatch{start: start, texts: texts[start:]})
+	}
+	return batches
+}
+
+// contextReserve is the context left for the instructions and numbering
+// around the texts of a request
+const contextReserve = 256
+
+// inputBudget returns the estimated tokens of texts one API call of field may
+// carry: the batch token budget, lowered so prompt, texts and translations fit
+// the model's context window when its size is known. Translations are taken
+// to be about as long as their sources. 0 means no budget.
+func (dt *DeepSeekTranslator) inputBudget(field string) int {
+	budget := dt.batchTokens
+	if dt.contextSize <= 0 {
+		return budget
+	}
+
+	prompt, _ := dt.prompts.systemPrompt(field, numberedListProtocol)
+	fit := (dt.contextSize - estimateTokens(prompt) - contextReserve) / 2
+	if fit < 1 {
+		fit = 1
+	}
+	if budget == 0 || fit < budget {
+		budget = fit
+	}
+	return budget
+}
+
+// splitForAPI returns how texts of field are split into API calls: packed by
+// the token budget when there is one, otherwise by the sub-batch size
+func (dt *DeepSeekTranslator) splitForAPI(field string, texts []string) []subBatch {
+	if budget := dt.inputBudget(field); budget > 0 {
+		return packBatch(texts, budget, dt.subBatchFor(field))
+	}
+	if size := dt.subBatchFor(field); size > 0 {
+		return splitBatch(texts, size)
+	}
+	return []subBatch{{start: 0, texts: texts}}
+}
+
+// subBatchFor returns the sub-batch size of field: its override when it has
+// one, otherwise subBatchSize
+func (dt *DeepSeekTranslator) subBatchFor(field string) int {
+	if size, ok := dt.fieldSubBatch[field]; ok {
+		return size
+	}
+	return dt.subBatchSize
+}
+
+// concurrencyFor returns how many API calls of field may run at once: its
+// override when it has one, otherwise concurrency
+func (dt *DeepSeekTranslator) concurrencyFor(field string) int {
+	if concurrency, ok := dt.fieldConcurrency[field]; ok {
+		return concurrency
+	}
+	return dt.concurrency
+}
+
+// callLimit returns how many API calls may run in parallel: the slow-start
+// allowance when a ramp is configured, capped at concurrency
+func (dt *DeepSeekTranslator) callLimit(concurrency int) int {
+	if dt.ramp != nil && dt.ramp.limit() < concurrency {
+		return dt.ramp.limit()
+	}
+	return concurrency
+}
+
+// translateSubBatches translates the sub-batches of texts, running up to the
+// field's concurrency API calls at once. Count mismatches of the sub-batches
+// add up to one *CountMismatchError. A sub-batch that fails otherwise only
+// loses its own texts, listed as missing with the failure as Err, so the
+// others are still used; the batch fails as a whole only when all of them do.
+func (dt *DeepSeekTranslator) translateSubBatches(ctx context.Context, field string, texts []string, batches []subBatch) ([]string, error) {
+	concurrency := dt.concurrencyFor(field)
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	log.Printf("✂️  拆分为 %d 个子批次 (并发 %d)", len(batches), concurrency)
+
+	results := make([]string, len(texts))
+	errs := make([]error, len(batches))
+	done := make(chan struct{}, len(batches))
+	running := 0
+
+	for i, batch := range batches {
+		for running >= dt.callLimit(concurrency) {
+			<-done
+			running--
+		}
+		running++
+		go func(i int, batch subBatch) {
+			defer func() { done <- struct{}{} }()
+
+			translations, err := dt.translateBatch(ctx, field, batch.texts)
+			copy(results[batch.start:batch.start+len(batch.texts)], translations)
+			errs[i] = err
+			if dt.ramp != nil && !errors.Is(err, ErrCountMismatch) {
+				// A wrong item count is the model's fault, not throttling
+				dt.ramp.record(err)
+			}
+		}(i, batch)
+	}
+	for ; running > 0; running-- {
+		<-done
+	}
+
+	var mismatch *CountMismatchError
+	var failed error
+	failures := 0
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if mismatch == nil {
+			mismatch = &CountMismatchError{}
+		}
+		var m *CountMismatchError
+		if !errors.As(err, &m) {
+			log.Printf("⚠️  子批次 %d/%d 失败，其 %d 个文本保留待翻译: %v", i+1, len(batches), len(batches[i].texts), err)
+			if failed == nil {
+				failed = err
+			}
+			failures++
+			mismatch.Want += len(batches[i].texts)
+			for j, text := range batches[i].texts {
+				results[batches[i].start+j] = text
+				mismatch.Missing = append(mismatch.Missing, batches[i].start+j)
+			}
+			continue
+		}
+		mismatch.Got += m.Got
+		mismatch.Want += m.Want
+		for _, index := range m.Missing {
+			mismatch.Missing = append(mismatch.Missing, batches[i].start+index)
+		}
+	}
+	if failures == len(batches) {
+		return texts, failed
+	}
+	if mismatch != nil {
+		mismatch.Err = failed
+		// Sub-batches that matched count towards both sides
+		for i, err := range errs {
+			if err == nil {
+				mismatch.Got += len(batches[i].texts)
+				mismatch.Want += len(batches[i].texts)
+			}
+		}
+		return results, mismatch
+	}
+	return results, nil
+}
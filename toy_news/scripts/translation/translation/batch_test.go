@@ -0,0 +1,119 @@
+package translation
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// batchShape returns the start and size of every sub-batch
+func batchShape(batches []subBatch) [][2]int {
+	var shape [][2]int
+	for _, b := range batches {
+		shape = append(shape, [2]int{b.start, len(b.texts)})
+	}
+	return shape
+}
+
+func TestSplitBatch(t *testing.T) {
+	texts := []string{"a", "b", "c", "d", "e"}
+	tests := []struct {
+		size int
+		want [][2]int
+	}{
+		{2, [][2]int{{0, 2}, {2, 2}, {4, 1}}},
+		{5, [][2]int{{0, 5}}},
+		{10, [][2]int{{0, 5}}},
+		{1, [][2]int{{0, 1}, {1, 1}, {2, 1}, {3, 1}, {4, 1}}},
+	}
+	for _, tt := range tests {
+		if got := batchShape(splitBatch(texts, tt.size)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitBatch(size %d) = %v, want %v", tt.size, got, tt.want)
+		}
+	}
+	if got := splitBatch(nil, 3); len(got) != 0 {
+		t.Errorf("splitBatch(nil) = %v, want no sub-batches", got)
+	}
+}
+
+func TestPackBatch(t *testing.T) {
+	// "アイウエ" estimates 4 tokens, "abcdefgh" 2 tokens
+	tests := []struct {
+		name     string
+		texts    []string
+		budget   int
+		maxTexts int
+		want     [][2]int
+	}{
+		{"short texts share a call", []string{"abcdefgh", "abcdefgh", "abcdefgh"}, 4, 0, [][2]int{{0, 2}, {2, 1}}},
+		{"long text gets its own call", []string{"abcdefgh", "アイウエアイウエ", "abcdefgh"}, 5, 0, [][2]int{{0, 1}, {1, 1}, {2, 1}}},
+		{"text count cap", []string{"a", "b", "c", "d", "e"}, 100, 2, [][2]int{{0, 2}, {2, 2}, {4, 1}}},
+		{"everything fits", []string{"アイウエ", "abcdefgh"}, 6, 0, [][2]int{{0, 2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := batchShape(packBatch(tt.texts, tt.budget, tt.maxTexts)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("packBatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"abcd", 1},
+		{"abcde", 2},
+		{"ガンダム", 4},
+		{"RG ガンダム", 5},
+	}
+	for _, tt := range tests {
+		if got := estimateTokens(tt.text); got != tt.want {
+			t.Errorf("estimateTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestFailedSubBatchKeepsOthers(t *testing.T) {
+	dt, _ := newFakeAPI(t, func(call int, texts []string) (int, string, string) {
+		for _, text := range texts {
+			if text == "黄" {
+				return http.StatusBadRequest, "", ""
+			}
+		}
+		return http.StatusOK, numberedAnswer(texts), "stop"
+	})
+	dt.subBatchSize = 2
+	ts := newTestService(dt, WithFieldConfig("name", FieldConfig{NoCache: true}))
+	ts.fieldsToTranslate = []string{"name"}
+
+	var items []PendingItem
+	for _, name := range []string{"赤", "青", "黄", "緑", "白", "黒"} {
+		items = append(items, PendingItem{ProductHash: name, Name: name})
+	}
+	translated, err := ts.TranslateWithCache(context.Background(), items)
+	if err != nil {
+		t.Fatalf("TranslateWithCache: %v", err)
+	}
+	// The sub-batch of 黄 stays pending, the other two are kept
+	var pending []string
+	for i := range translated {
+		source, translation := translated[i].fieldValues("name")
+		switch {
+		case translation == "":
+			pending = append(pending, source)
+			if translated[i].fieldErrors["name"] == "" {
+				t.Errorf("%s has no failure recorded", source)
+			}
+		case translation != "译:"+source:
+			t.Errorf("translation of %s = %q", source, translation)
+		}
+	}
+	if len(pending) != 2 || (pending[0] != "黄" && pending[1] != "黄") {
+		t.Errorf("pending = %q, want 黄 and the other text of its sub-batch", pending)
+	}
+}
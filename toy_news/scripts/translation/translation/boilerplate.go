@@ -0,0 +1,85 @@
+package translation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// stripRule removes boilerplate matching re, such as "【予約】", from a field's
+// source text before it is translated
+type stripRule struct {
+	field string
+	re    *regexp.Regexp
+}
+
+// affix is the boilerplate stripped from the start and end of a text, to be
+// put back around its translation
+type affix struct {
+	prefix string
+	suffix string
+}
+
+// wrap puts the stripped boilerplate back around a translation
+func (a affix) wrap(translation string) string {
+	return a.prefix + translation + a.suffix
+}
+
+// parseStripRules compiles strip rules given as field=regex
+func parseStripRules(specs []string) ([]stripRule, error) {
+	var rules []stripRule
+	for _, spec := range specs {
+		field, pattern, ok := strings.Cut(spec, "=")
+		if !ok || field == "" || pattern == "" {
+			return nil, fmt.Errorf("expected field=regex, got %q", spec)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid strip pattern %q: %w", pattern, err)
+		}
+		rules = append(rules, stripRule{field: field, re: re})
+	}
+	return rules, nil
+}
+
+// stripBoilerplate removes the boilerplate of field's strip rules from text
+// and returns the text to translate. With restoreStripped, matches at the
+// start and end of the text are returned as the affix to re-add; matches
+// elsewhere are always dropped. A text that is nothing but boilerplate is
+// translated as is.
+func (ts *TranslationService) stripBoilerplate(field, text string) (string, affix) {
+	stripped := text
+	var a affix
+	for _, rule := range ts.stripRules {
+		if rule.field != field {
+			continue
+		}
+
+		var kept strings.Builder
+		last := 0
+		for _, loc := range rule.re.FindAllStringIndex(stripped, -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			match := stripped[loc[0]:loc[1]]
+			switch {
+			case loc[0] == 0:
+				a.prefix += match
+			case loc[1] == len(stripped):
+				a.suffix = match + a.suffix
+			}
+			kept.WriteString(stripped[last:loc[0]])
+			last = loc[1]
+		}
+		kept.WriteString(stripped[last:])
+		stripped = strings.TrimSpace(kept.String())
+	}
+
+	if stripped == "" {
+		return text, affix{}
+	}
+	if !ts.restoreStripped {
+		return stripped, affix{}
+	}
+	return stripped, a
+}
@@ -0,0 +1,47 @@
+package translation
+
+import "testing"
+
+func TestStripBoilerplateRoundTrip(t *testing.T) {
+	rules, err := parseStripRules([]string{`name=【[^】]*】`, `name=\(送料無料\)`, `description=※.*$`})
+	if err != nil {
+		t.Fatalf("parseStripRules: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		field        string
+		text         string
+		restore      bool
+		wantStripped string
+		wantWrapped  string // of the translation "X"
+	}{
+		{"prefix restored", "name", "【予約】ガンダム", true, "ガンダム", "【予約】X"},
+		{"prefix and suffix restored", "name", "【予約】ガンダム(送料無料)", true, "ガンダム", "【予約】X(送料無料)"},
+		{"middle match dropped", "name", "ガンダム【限定】ザク", true, "ガンダムザク", "X"},
+		{"dropped without restore", "name", "【予約】ガンダム", false, "ガンダム", "X"},
+		{"only boilerplate kept as is", "name", "【予約】", true, "【予約】", "X"},
+		{"other field untouched", "maker", "【予約】バンダイ", true, "【予約】バンダイ", "X"},
+		{"suffix rule of another field", "description", "説明文※注意事項", true, "説明文", "X※注意事項"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := &TranslationService{stripRules: rules, restoreStripped: tt.restore}
+			stripped, a := ts.stripBoilerplate(tt.field, tt.text)
+			if stripped != tt.wantStripped {
+				t.Errorf("stripped = %q, want %q", stripped, tt.wantStripped)
+			}
+			if got := a.wrap("X"); got != tt.wantWrapped {
+				t.Errorf("wrap(X) = %q, want %q", got, tt.wantWrapped)
+			}
+		})
+	}
+}
+
+func TestParseStripRulesInvalid(t *testing.T) {
+	for _, spec := range []string{"name", "=abc", "name=", "name=("} {
+		if _, err := parseStripRules([]string{spec}); err == nil {
+			t.Errorf("parseStripRules(%q) should fail", spec)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package translation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AgeBucket counts cache entries whose last update falls in an age range
+type AgeBucket struct {
+	Label      string
+	MaxAge     time.Duration // upper bound, 0 for the open-ended last bucket
+	Entries    int64
+	TotalUsage int64
+}
+
+// newAgeBuckets returns the empty buckets of the cache age report
+func newAgeBuckets() []AgeBucket {
+	day := 24 * time.Hour
+	return []AgeBucket{
+		{Label: "<1d", MaxAge: day},
+		{Label: "1-7d", MaxAge: 7 * day},
+		{Label: "7-30d", MaxAge: 30 * day},
+		{Label: ">30d"},
+	}
+}
+
+// addToAgeBuckets counts one entry of the given age in its bucket
+func addToAgeBuckets(buckets []AgeBucket, age time.Duration, usage int64) {
+	for i := range buckets {
+		if buckets[i].MaxAge == 0 || age < buckets[i].MaxAge {
+			buckets[i].Entries++
+			buckets[i].TotalUsage += usage
+			return
+		}
+	}
+}
+
+// CacheAgeReport buckets the cache entries by the age of their updated_at
+// relative to now, summing their usage counts
+func (ts *TranslationService) CacheAgeReport(ctx context.Context, now time.Time) ([]AgeBucket, error) {
+	opts := options.Find().SetProjection(bson.M{"updated_at": 1, "usage_count": 1})
+	cursor, err := ts.cacheCollection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding cache entries: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	buckets := newAgeBuckets()
+	for cursor.Next(ctx) {
+		var entry CacheItem
+		err = cursor.Decode(&entry)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding cache entry: %w", err)
+		}
+		addToAgeBuckets(buckets, now.Sub(entry.UpdatedAt), int64(entry.UsageCount))
+	}
+	if err = cursor.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating cache entries: %w", err)
+	}
+
+	return buckets, nil
+}
+
+// PrintCacheAgeReport prints the cache age distribution
+func PrintCacheAgeReport(buckets []AgeBucket) {
+	var total int64
+	for _, bucket := range buckets {
+		total += bucket.Entries
+	}
+
+	fmt.Printf("Translation cache age (by updated_at), %d entries:\n", total)
+	for _, bucket := range buckets {
+		share := 0.0
+		if total > 0 {
+			share = float64(bucket.Entries) * 100 / float64(total)
+		}
+		fmt.Printf("  %-6s %8d entries (%5.1f%%), %d total uses\n", bucket.Label, bucket.Entries, share, bucket.TotalUsage)
+	}
+}
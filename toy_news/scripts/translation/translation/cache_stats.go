@@ -0,0 +1,92 @@
+package translation
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// fieldCacheStats counts cache hits and misses per field since startup
+type fieldCacheStats struct {
+	mu     sync.Mutex
+	hits   map[string]int64
+	misses map[string]int64
+}
+
+// record counts one cache lookup of field
+func (s *fieldCacheStats) record(field string, hit bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.hits == nil {
+		s.hits = make(map[string]int64)
+		s.misses = make(map[string]int64)
+	}
+	if hit {
+		s.hits[field]++
+	} else {
+		s.misses[field]++
+	}
+}
+
+// counts returns the hits and misses of field
+func (s *fieldCacheStats) counts(field string) (int64, int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits[field], s.misses[field]
+}
+
+// fields returns the fields with recorded lookups in name order
+func (s *fieldCacheStats) fields() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var fields []string
+	for field := range s.hits {
+		fields = append(fields, field)
+	}
+	for field := range s.misses {
+		if _, ok := s.hits[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// FieldCacheCounts represents the cache hits and misses of one field
+type FieldCacheCounts struct {
+	Hits   int64 `json:"hits" bson:"hits"`
+	Misses int64 `json:"misses" bson:"misses"`
+}
+
+// snapshot returns the hits and misses of every field with recorded lookups
+func (s *fieldCacheStats) snapshot() map[string]FieldCacheCounts {
+	counts := make(map[string]FieldCacheCounts)
+	for _, field := range s.fields() {
+		hits, misses := s.counts(field)
+		counts[field] = FieldCacheCounts{Hits: hits, Misses: misses}
+	}
+	return counts
+}
+
+// cacheDelta returns the lookups of each field recorded between the start
+// and end snapshots, leaving out fields without any
+func cacheDelta(start, end map[string]FieldCacheCounts) map[string]FieldCacheCounts {
+	delta := make(map[string]FieldCacheCounts)
+	for field, counts := range end {
+		counts.Hits -= start[field].Hits
+		counts.Misses -= start[field].Misses
+		if counts.Hits != 0 || counts.Misses != 0 {
+			delta[field] = counts
+		}
+	}
+	return delta
+}
+
+// print prints the hit rate of each field with recorded lookups
+func (s *fieldCacheStats) print() {
+	for _, field := range s.fields() {
+		hits, misses := s.counts(field)
+		fmt.Printf("Cache hit rate %s: %d hits, %d misses (%.1f%%)\n",
+			field, hits, misses, float64(hits)*100/float64(hits+misses))
+	}
+}
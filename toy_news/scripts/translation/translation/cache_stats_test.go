@@ -0,0 +1,24 @@
+package translation
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCacheDeltaByField(t *testing.T) {
+	var stats fieldCacheStats
+	stats.record("name", true)
+	stats.record("description", false)
+	start := stats.snapshot()
+
+	stats.record("name", true)
+	stats.record("name", false)
+	stats.record("maker", true)
+	want := map[string]FieldCacheCounts{
+		"name":  {Hits: 1, Misses: 1},
+		"maker": {Hits: 1},
+	}
+	if got := cacheDelta(start, stats.snapshot()); !reflect.DeepEqual(got, want) {
+		t.Errorf("cacheDelta = %v, want %v", got, want)
+	}
+}
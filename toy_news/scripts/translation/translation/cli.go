@@ -0,0 +1,753 @@
+package translation
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Main runs the translation service command line: it parses the flags, then
+// runs one of the one-off commands or the service loop
+func Main() {
+	// Command line flags
+	// usage: go run . -mongo-uri "mongodb://localhost:27017/" -mongo-db "scrapy_items" -mongo-collection "toys_normalized" -show-stats
+	var (
+		interval         = flag.Int("interval", 10, "Check interval in seconds")
+		mongoURI         = flag.String("mongo-uri", "mongodb://localhost:27017/", "MongoDB URI")
+		mongoDB          = flag.String("mongo-db", "scrapy_items", "MongoDB database")
+		mongoCollection  = flag.String("mongo-collection", "toys_normalized", "MongoDB collection")
+		showStats        = flag.Bool("show-stats", false, "Show statistics and exit")
+		bulkOrdered      = flag.Bool("bulk-ordered", true, "Use ordered bulk writes (false keeps applying updates after a failed one)")
+		disposition      = flag.String("pending-disposition", "delete", "What to do with processed pending items: delete, archive or mark")
+		idleExitAfter    = flag.Duration("idle-exit-after", 0, "Exit after being idle for this long, e.g. 10m (0 disables)")
+		diff             = flag.Bool("diff", false, "Re-translate stored products, print how the results differ and exit without writing")
+		diffLimit        = flag.Int("diff-limit", 20, "Number of normalized products to compare in -diff mode")
+		maxRetries       = flag.Int("max-retries", 3, "Retries of a failed API call on rate limiting, server errors and dropped connections, with exponential backoff from 1s (see -retry-jitter; 0 fails at once)")
+		retryJitter      = flag.String("retry-jitter", JitterFull, "Jitter applied to API retry backoff: full, equal or none")
+		once             = flag.Bool("once", false, "Process a single batch and exit")
+		maxCost          = flag.Float64("max-cost", 0, "Stop calling the API once the estimated spend reaches this many USD (0 disables)")
+		inputPrice       = flag.Float64("price-input", 0.27, "API price in USD per million prompt tokens")
+		outputPrice      = flag.Float64("price-output", 1.10, "API price in USD per million completion tokens")
+		memoryCacheSize  = flag.Int("memory-cache-size", 0, "Entries kept in an in-memory LRU in front of the MongoDB cache (0 disables)")
+		skipExisting     = flag.Bool("skip-existing", false, "Only translate fields that have no translation in the normalized collection yet")
+		drain            = flag.Bool("drain", false, "With -once, stream the whole pending queue in batches instead of a single batch")
+		plainSingle      = flag.Bool("plain-single-text", true, "Translate single-text batches with a plain prompt instead of the numbered list")
+		pauseFile        = flag.String("pause-file", "", "Pause processing while this file exists (SIGUSR1 toggles and SIGUSR2 resumes as well)")
+		systemPrompt     = flag.String("system-prompt", "", "System prompt template used for all fields ({{.Field}} is the field name)")
+		refreshStale     = flag.Bool("refresh-stale-cache", false, "Treat cache entries from a different provider, model or prompt version as misses")
+		exportPath       = flag.String("export-translated", "", "Export translated products to this file (.csv for CSV, JSON lines otherwise) and exit")
+		since            = flag.String("since", "", "With -export-translated, only export products updated since this date (YYYY-MM-DD or RFC 3339)")
+		fieldPrompts     = keyValueFlag{}
+		fieldMaxTokens   = keyValueFlag{}
+		fieldMaxChars    = keyValueFlag{}
+		fieldSubBatch    = keyValueFlag{}
+		fieldConcurrency = keyValueFlag{}
+		statsFull        = flag.Bool("stats-full", false, "Include the normalized collection counts in the stats shown after each cycle")
+		cacheAgeReport   = flag.Bool("cache-age-report", false, "Show how old the cache entries are and how often they are used, then exit")
+		sampleRate       = flag.Float64("sample-rate", 1, "Fraction of fetched items to translate, e.g. 0.05 for a canary run (the rest stays pending)")
+		sampleSeed       = flag.Int64("sample-seed", 0, "Seed for -sample-rate, for reproducible samples (0 picks a random seed)")
+		parseResponse    = flag.String("parse-response", "", "Print how a raw API response read from this file is parsed and exit")
+		expectedCount    = flag.Int("expected-count", 0, "With -parse-response, the number of texts that were sent")
+		provider         = flag.String("provider", "deepseek", "Translation provider: deepseek, local (OpenAI-compatible server) or stub for offline runs")
+		apiBase          = flag.String("api-base", "", "API base URL (default https://api.deepseek.com, or http://localhost:11434/v1 for local)")
+		model            = flag.String("model", "", "Model name (default deepseek-chat; required for local)")
+		subBatchSize     = flag.Int("sub-batch-size", 0, "Split API calls into chunks of at most this many texts (0 sends each field's batch in one call)")
+		concurrency      = flag.Int("concurrency", 1, "With -sub-batch-size or -batch-tokens, number of API calls made in parallel")
+		statusField      = flag.String("status-field", "", "Normalized field recording whether a product is fully or partially translated, such as translation_status (off when empty)")
+		newlineEscape    = flag.String("newline-escape", defaultNewlineEscape, "Marker replacing newlines inside texts sent as a numbered list (empty sends them as is)")
+		batchTokens      = flag.Int("batch-tokens", 0, "Pack API calls up to this many estimated source tokens instead of a fixed text count (0 disables)")
+		normalizeOutput  = flag.String("normalize-output", "", "Comma-separated transforms applied to translations before caching: fullwidth-punct, halfwidth, trim-space")
+		restoreStripped  = flag.Bool("restore-stripped", false, "Put boilerplate removed by -strip-pattern back at the start or end of the translation")
+		refusalPatterns  stringsFlag
+		stripPatterns    stringsFlag
+		ensembleWith     stringsFlag
+		symbolPatterns   stringsFlag
+		noCacheFields    stringsFlag
+		mapFields        stringsFlag
+		fieldPairs       stringsFlag
+		identityFields   stringsFlag
+		skipRules        stringsFlag
+		auditConsistency = flag.Bool("audit-consistency", false, "Compare a sample of stored translations with the cache, report mismatches and exit")
+		auditLimit       = flag.Int("audit-limit", 100, "Number of translated products sampled in -audit-consistency mode")
+		slowStart        = flag.Bool("slow-start", false, "Start parallel API calls at 1 and ramp up to -concurrency as calls succeed, halving after failures")
+		includeHashes    = flag.String("include-hashes", "", "Only process these product hashes (comma-separated, or a file with one per line)")
+		excludeHashes    = flag.String("exclude-hashes", "", "Never process these product hashes (comma-separated, or a file with one per line); they stay pending")
+		cycleReport      = flag.String("cycle-report", "", "Append a JSON report of every processing cycle (items, cache hits, API calls, updated products, failures, duration) to this JSONL file")
+		reportCollection = flag.String("cycle-report-collection", "", "Also insert the cycle reports into this MongoDB collection")
+		otlpEndpoint     = flag.String("otlp-metrics-endpoint", "", "Push cycle and API call metrics over OTLP/HTTP to this collector URL, such as http://localhost:4318 (off when empty)")
+		cacheMaxEntry    = flag.Int("cache-max-entry-bytes", maxMongoDocumentBytes, "Skip caching translations whose cache entry would be larger than this, still writing them to the normalized collection (0 disables)")
+		cacheWriteGrace  = flag.Duration("cache-write-grace", 5*time.Second, "How long a started cache write may continue after a forced shutdown (0 cancels it immediately)")
+		statsTimeout     = flag.Duration("stats-timeout", 10*time.Second, "Timeout of each stats query; counts that time out are shown as n/a (0 waits indefinitely)")
+		statsRetries     = flag.Int("stats-retries", 1, "Extra attempts for a failed stats query")
+		mongoRetries     = flag.Int("mongo-retries", 2, "Extra attempts for a MongoDB connection or bulk write failing on network trouble or timeouts")
+		maxWrites        = flag.Int("max-concurrent-writes", 0, "Most MongoDB bulk writes and multi-document deletes or updates in flight at once, across all pipelines (0 doesn't limit them)")
+		debugHash        = flag.String("debug-hash", "", "Replay the translation of this product_hash verbosely and exit without writing")
+		commit           = flag.Bool("commit", false, "With -debug-hash, store the result like a normal processing cycle")
+		outputEscape     = flag.String("output-escape", EscapeNone, "Escaping of the translations written to the normalized collection: none, html or json")
+		batchSize        = flag.Int("batch-size", 20, "Number of pending items fetched per processing cycle")
+		overwrite        = flag.String("overwrite", OverwriteAlways, "Whether translations replace existing target values: always, if-empty or never")
+		ensembleScorer   = flag.String("ensemble-scorer", "length", "With -ensemble-with, how the best translation is chosen: length")
+		adaptiveThrottle = flag.Float64("adaptive-throttle", 0, "Maximum API requests per second, lowered automatically while the error rate is high (0 disables)")
+		plan             = flag.Bool("plan", false, "Project cache hits, API calls, tokens and cost of processing the pending queue and exit without translating")
+		planLimit        = flag.Int("plan-limit", 0, "Number of pending items read in -plan mode (0 reads the whole queue)")
+		preserveSymbols  = flag.Bool("preserve-symbols", false, "Swap emoji and symbols for placeholders while translating and put them back verbatim afterwards")
+		validateProvider = flag.Bool("validate-provider", false, "Check that the API key works and the model is available, via the provider's models list or a trial translation, and exit")
+		pipelinesPath    = flag.String("pipelines", "", "JSON file of named pipelines, each with its own pending_collection, collection, cache_collection, target_language, target_suffix, provider, api_base, model, fields, batch_size and check_interval, run side by side")
+		listStart        = flag.Int("list-start", 1, "Number of the first item in the model's numbered answers, e.g. 0 for models counting from zero")
+		preserveMeasure  = flag.Bool("preserve-measurements", false, "Swap measurements such as 180mm or 1/7 for placeholders while translating and put them back verbatim afterwards")
+		timestampSource  = flag.String("timestamp-source", TimestampServer, "Clock of the updatedAt written with translations: server ($currentDate) or client")
+		maxBatchWait     = flag.Duration("max-batch-wait", 0, "With -drain, flush a partial batch once its first item has waited this long (0 always fills -batch-size)")
+		findOrphans      = flag.Bool("find-orphans", false, "Report pending items whose product_hash is not in the normalized collection and exit")
+		deadLetterOrphan = flag.Bool("dead-letter-orphans", false, "With -find-orphans, also move the orphaned items to toys_translation_dead_letter")
+		simulateNorm     = flag.Bool("simulate-normalization", false, "Report how many cache entries would share a key if source texts were normalized, and exit")
+		rehashCache      = flag.Bool("rehash-cache", false, "Recompute every cache key from its original text, merging entries that collide, and exit")
+		recordPath       = flag.String("record", "", "Append every API request and its response to this JSONL file")
+		replayPath       = flag.String("replay", "", "Serve API responses recorded with -record from this JSONL file instead of calling the API")
+		minSourceChars   = flag.Int("min-source-chars", 0, "Source texts with fewer characters are copied verbatim instead of translated (0 translates everything)")
+		modelContextSize = flag.Int("model-context-size", 0, "Context window of the model in tokens; batches estimated not to fit are split before sending (0 disables)")
+		sentenceCache    = flag.Bool("sentence-cache", false, "Translate and cache texts with several sentences sentence by sentence, so sentences shared across products hit the cache")
+		verifyWrites     = flag.Bool("verify-writes", false, "Read translations back after each bulk write and dead-letter the items whose translations didn't persist")
+		writeConcern     = flag.String("write-concern", "", "MongoDB write concern: majority, a tag set or a number of nodes (default: driver default)")
+		readConcern      = flag.String("read-concern", "", "MongoDB read concern: local, available, majority, linearizable or snapshot (default: driver default)")
+		reviewMaxChars   = flag.Int("review-max-chars", 0, "Hold translations longer than this many characters for review in toys_translation_review (0 disables)")
+		reviewMaxRatio   = flag.Float64("review-max-ratio", 0, "Hold translations more than this many times as long as their source for review (0 disables)")
+		promoteReviewed  = flag.Bool("promote-reviewed", false, "Write the approved translations of toys_translation_review to the normalized collection and exit")
+		maxAttempts      = flag.Int("max-attempts", 0, "Dead-letter pending items once they failed this many times (0 keeps retrying them)")
+		failureBackoff   = flag.Duration("failure-backoff", 0, "Wait before retrying a failed pending item, doubling with every failure up to 24h (0 retries every cycle)")
+		maxConsecErrors  = flag.Int("max-consecutive-errors", 0, "Exit with an error after this many API calls in a row failed, for an orchestrator to restart the service (0 keeps running)")
+		cacheMaxEntries  = flag.Int64("cache-max-entries", 0, "Keep the cache collection at most this big, evicting the least used and oldest entries (0 disables)")
+		cacheEvictEvery  = flag.Duration("cache-evict-interval", time.Hour, "How often the cache is evicted down to -cache-max-entries")
+		pendingQuery     = flag.String("pending-filter", "", "Only process pending items matching this MongoDB query in extended JSON, e.g. {\"maker\": \"Bandai\"}")
+		migrateFields    = flag.Bool("migrate-fields", false, "Rename nameCN and descriptionCN across the normalized collection to the -field targets of name and description and exit")
+	)
+	flag.Var(fieldPrompts, "field-prompt", "Per-field system prompt template as field=template, repeatable")
+	flag.Var(fieldMaxTokens, "field-max-tokens", "Per-field API output token cap per text as field=tokens, repeatable")
+	flag.Var(fieldMaxChars, "field-max-chars", "Per-field translation length limit as field=characters, asked for in the prompt and logged when exceeded, repeatable")
+	flag.Var(fieldSubBatch, "field-sub-batch-size", "Per-field -sub-batch-size as field=texts, repeatable")
+	flag.Var(fieldConcurrency, "field-concurrency", "Per-field -concurrency as field=calls, repeatable")
+	flag.Var(&refusalPatterns, "refusal-pattern", "Extra regex marking a model output as a refusal, repeatable")
+	flag.Var(&ensembleWith, "ensemble-with", "Also translate with provider[:model][@api-base] and keep the best scored result, repeatable (multiplies API calls)")
+	flag.Var(&symbolPatterns, "symbol-pattern", "Extra regex of symbols kept verbatim with -preserve-symbols or -preserve-measurements, e.g. [\\x{2460}-\\x{2473}], repeatable")
+	flag.Var(&noCacheFields, "no-cache-field", "Field translated by the API every time and never read from or written to the cache, repeatable")
+	flag.Var(&skipRules, "skip-if", "Leave a field untranslated for items whose pending document has path=value, as [field:]path=value, e.g. noTranslate=true or description:sourceLang=zh, repeatable")
+	flag.Var(&identityFields, "allow-identical", "Field whose translation may equal its source text, which is otherwise kept pending as untranslated, repeatable")
+	flag.Var(&fieldPairs, "field", "Also translate a field into another as source=target, e.g. name_raw=name_zh leaves name_raw untouched, repeatable")
+	flag.Var(&mapFields, "map-field", "Also translate a localized map field as map.source=target, e.g. localizedName.ja=zh fills localizedName.zh, repeatable")
+	flag.Var(&stripPatterns, "strip-pattern", "Boilerplate removed from a field before translating as field=regex, e.g. name=^【[^】]*】, repeatable")
+	flag.Parse()
+
+	// Every line of this run carries its ID, and the instance's when set
+	runID := newRunID()
+	log.SetPrefix(logPrefix(runID))
+
+	if *parseResponse != "" {
+		// Only inspect a captured response, no API key or MongoDB needed
+		err := PrintParsedResponse(*parseResponse, *expectedCount, *listStart)
+		if err != nil {
+			log.Fatalf("Error parsing response: %v", err)
+		}
+		return
+	}
+
+	if *sampleRate <= 0 || *sampleRate > 1 {
+		log.Fatalf("Invalid -sample-rate %v (expected a fraction in (0, 1])", *sampleRate)
+	}
+
+	if *interval < minCheckInterval {
+		log.Fatalf("Invalid -interval %d (expected at least %d second)", *interval, minCheckInterval)
+	}
+
+	if *batchSize < 1 {
+		log.Fatalf("Invalid -batch-size %d (expected at least 1)", *batchSize)
+	}
+
+	if *concurrency < 1 {
+		log.Fatalf("Invalid -concurrency %d (expected at least 1)", *concurrency)
+	}
+
+	if *subBatchSize < 0 || *batchTokens < 0 {
+		log.Fatal("-sub-batch-size and -batch-tokens can't be negative")
+	}
+
+	if *drain && !*once {
+		log.Fatal("-drain can only be used together with -once")
+	}
+
+	if *maxRetries < 0 {
+		log.Fatalf("Invalid -max-retries %d (expected at least 0)", *maxRetries)
+	}
+
+	if *mongoRetries < 0 {
+		log.Fatalf("Invalid -mongo-retries %d (expected at least 0)", *mongoRetries)
+	}
+
+	if *maxWrites < 0 {
+		log.Fatalf("Invalid -max-concurrent-writes %d (expected at least 0)", *maxWrites)
+	}
+	// One semaphore bounds the writes of every service
+	writeSlots := newWriteSemaphore(*maxWrites)
+
+	jitter, err := parseJitter(*retryJitter)
+	if err != nil {
+		log.Fatalf("Invalid -retry-jitter: %v", err)
+	}
+
+	switch *disposition {
+	case "delete", "archive", "mark":
+	default:
+		log.Fatalf("Invalid -pending-disposition %q (expected delete, archive or mark)", *disposition)
+	}
+
+	// Properly encode MongoDB URI with special characters
+	encodedURI := encodeMongoURI(*mongoURI)
+
+	var tape *apiTape
+	switch {
+	case *recordPath != "" && *replayPath != "":
+		log.Fatal("-record and -replay can't be used together")
+	case *recordPath != "":
+		tape, err = recordTape(*recordPath)
+		if err != nil {
+			log.Fatalf("Invalid -record: %v", err)
+		}
+		defer tape.Close()
+	case *replayPath != "":
+		tape, err = replayTape(*replayPath)
+		if err != nil {
+			log.Fatalf("Invalid -replay: %v", err)
+		}
+	}
+
+	var metrics *serviceMetrics
+	if *otlpEndpoint != "" {
+		provider, err := newOTLPMeterProvider(context.Background(), *otlpEndpoint, runID)
+		if err != nil {
+			log.Fatalf("Invalid -otlp-metrics-endpoint: %v", err)
+		}
+		// Shutting down pushes the readings of the last cycles
+		defer provider.Shutdown(context.Background())
+		metrics, err = newServiceMetrics(provider.Meter(meterName))
+		if err != nil {
+			log.Fatalf("Failed to create metrics: %v", err)
+		}
+	}
+
+	// The shared flags configure the translators and services of a single
+	// run and of every pipeline alike
+	configureTranslator := func(member Translator) {
+		dt, ok := member.(*DeepSeekTranslator)
+		if !ok {
+			return
+		}
+		dt.retryJitter = jitter
+		dt.maxRetries = *maxRetries
+		dt.plainSingleText = *plainSingle
+		dt.verbose = *debugHash != ""
+		dt.subBatchSize = *subBatchSize
+		dt.batchTokens = *batchTokens
+		dt.contextSize = *modelContextSize
+		dt.newlineEscape = *newlineEscape
+		dt.listStart = *listStart
+		dt.concurrency = *concurrency
+		dt.tape = tape
+		dt.metrics = metrics
+		dt.fieldSubBatch, err = fieldSubBatch.ints()
+		if err != nil {
+			log.Fatalf("Invalid -field-sub-batch-size: %v", err)
+		}
+		dt.fieldConcurrency, err = fieldConcurrency.ints()
+		if err != nil {
+			log.Fatalf("Invalid -field-concurrency: %v", err)
+		}
+		if *slowStart {
+			// Ramp up to the highest concurrency of any field
+			rampMax := *concurrency
+			for _, n := range dt.fieldConcurrency {
+				if n > rampMax {
+					rampMax = n
+				}
+			}
+			dt.ramp = newConcurrencyRamp(rampMax)
+		}
+		if *adaptiveThrottle > 0 {
+			dt.throttle = newAdaptiveThrottle(*adaptiveThrottle)
+		}
+		if *preserveSymbols || *preserveMeasure {
+			dt.symbols, err = newSymbolMasker(*preserveSymbols, *preserveMeasure, symbolPatterns)
+			if err != nil {
+				log.Fatalf("Invalid -symbol-pattern: %v", err)
+			}
+		}
+		dt.prompts, err = newPromptSet(*systemPrompt, fieldPrompts)
+		if err != nil {
+			log.Fatalf("Invalid prompt configuration: %v", err)
+		}
+		dt.limits.maxTokens, err = fieldMaxTokens.ints()
+		if err != nil {
+			log.Fatalf("Invalid -field-max-tokens: %v", err)
+		}
+		dt.limits.maxChars, err = fieldMaxChars.ints()
+		if err != nil {
+			log.Fatalf("Invalid -field-max-chars: %v", err)
+		}
+		dt.usage.maxCost = *maxCost
+		if dt.provider == "deepseek" {
+			// Local servers are free, their spend stays at zero
+			dt.usage.inputPrice = *inputPrice
+			dt.usage.outputPrice = *outputPrice
+		}
+	}
+
+	pairTargets, err := parseFieldPairs(fieldPairs)
+	if err != nil {
+		log.Fatalf("Invalid -field: %v", err)
+	}
+	mapTargets, err := parseMapFields(mapFields)
+	if err != nil {
+		log.Fatalf("Invalid -map-field: %v", err)
+	}
+	var opts []Option
+	configured := make(map[string]bool)
+	for _, spec := range append(append([]string{}, fieldPairs...), mapFields...) {
+		field, _, _ := strings.Cut(spec, "=")
+		if configured[field] {
+			log.Fatalf("Field %s is configured more than once", field)
+		}
+		configured[field] = true
+		target := pairTargets[field]
+		if target == "" {
+			target = mapTargets[field]
+		}
+		opts = append(opts, WithFieldConfig(field, FieldConfig{Target: target}))
+	}
+
+	var cycleReportFile *reportFile
+	if *cycleReport != "" {
+		cycleReportFile, err = openReportFile(*cycleReport)
+		if err != nil {
+			log.Fatalf("Invalid -cycle-report: %v", err)
+		}
+		defer cycleReportFile.Close()
+	}
+	opts = append(opts, func(service *TranslationService) {
+		service.bulkOrdered = *bulkOrdered
+		service.pendingDisposition = *disposition
+		service.batchSize = *batchSize
+		service.idleExitAfter = *idleExitAfter
+		service.skipExisting = *skipExisting
+		service.drain = *drain
+		service.minSourceChars = *minSourceChars
+		service.sentenceCache = *sentenceCache
+		service.verifyWrites = *verifyWrites
+		service.maxConsecutiveErrors = *maxConsecErrors
+		service.maxAttempts = *maxAttempts
+		service.failureBackoff = *failureBackoff
+		if len(skipRules) > 0 {
+			rules, err := parseSkipRules(skipRules)
+			if err != nil {
+				log.Fatalf("Invalid -skip-if: %v", err)
+			}
+			service.SetFieldPredicate(skipRulesPredicate(rules))
+		}
+		if len(identityFields) > 0 {
+			service.identityFields = make(map[string]bool, len(identityFields))
+			for _, field := range identityFields {
+				service.identityFields[field] = true
+			}
+		}
+		service.queryFilter, err = parseQueryFilter(*pendingQuery)
+		if err != nil {
+			log.Fatalf("Invalid -pending-filter: %v", err)
+		}
+		if *cacheMaxEntries < 0 || *cacheEvictEvery <= 0 {
+			log.Fatal("-cache-max-entries can't be negative and -cache-evict-interval must be positive")
+		}
+		service.cacheMaxEntries = *cacheMaxEntries
+		service.cacheEvictInterval = *cacheEvictEvery
+		service.review = reviewThresholds{maxChars: *reviewMaxChars, maxRatio: *reviewMaxRatio}
+		service.writeConcern, err = parseWriteConcern(*writeConcern)
+		if err != nil {
+			log.Fatalf("Invalid -write-concern: %v", err)
+		}
+		service.readConcern, err = parseReadConcern(*readConcern)
+		if err != nil {
+			log.Fatalf("Invalid -read-concern: %v", err)
+		}
+		if len(noCacheFields) > 0 {
+			service.noCacheFields = make(map[string]bool, len(noCacheFields))
+			for _, field := range noCacheFields {
+				service.noCacheFields[field] = true
+			}
+		}
+		service.maxBatchWait = *maxBatchWait
+		service.pauseFile = *pauseFile
+		service.strictProvenance = *refreshStale
+		service.statsFull = *statsFull
+		service.statsTimeout = *statsTimeout
+		service.cacheWriteGrace = *cacheWriteGrace
+		service.cacheMaxEntryBytes = *cacheMaxEntry
+		service.reportCollectionName = *reportCollection
+		service.reportFile = cycleReportFile
+		service.metrics = metrics
+		service.statsRetries = *statsRetries
+		service.mongoRetries = *mongoRetries
+		service.writeSlots = writeSlots
+		service.sampleRate = *sampleRate
+		service.statusField = *statusField
+		service.outputEscape, err = parseOutputEscape(*outputEscape)
+		if err != nil {
+			log.Fatalf("Invalid -output-escape: %v", err)
+		}
+		service.overwrite, err = parseOverwrite(*overwrite)
+		if err != nil {
+			log.Fatalf("Invalid -overwrite: %v", err)
+		}
+		service.timestampSource, err = parseTimestampSource(*timestampSource)
+		if err != nil {
+			log.Fatalf("Invalid -timestamp-source: %v", err)
+		}
+		seed := *sampleSeed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		service.sampler = rand.New(rand.NewSource(seed))
+		service.refusalPatterns, err = compileRefusalPatterns(refusalPatterns)
+		if err != nil {
+			log.Fatalf("Invalid -refusal-pattern: %v", err)
+		}
+		service.outputTransforms, err = parseOutputTransforms(*normalizeOutput)
+		if err != nil {
+			log.Fatalf("Invalid -normalize-output: %v", err)
+		}
+		service.stripRules, err = parseStripRules(stripPatterns)
+		if err != nil {
+			log.Fatalf("Invalid -strip-pattern: %v", err)
+		}
+		service.restoreStripped = *restoreStripped
+		service.includeHashes, err = parseHashList(*includeHashes)
+		if err != nil {
+			log.Fatalf("Invalid -include-hashes: %v", err)
+		}
+		service.excludeHashes, err = parseHashList(*excludeHashes)
+		if err != nil {
+			log.Fatalf("Invalid -exclude-hashes: %v", err)
+		}
+		if *memoryCacheSize > 0 {
+			service.memoryCache = newLRUCache(*memoryCacheSize)
+		}
+	})
+
+	if *pipelinesPath != "" {
+		if len(ensembleWith) > 0 || *once {
+			log.Fatal("-pipelines can't be combined with -ensemble-with or -once")
+		}
+		// Every pipeline gets a service of its own configured from the file
+		pipelines, err := loadPipelines(*pipelinesPath)
+		if err != nil {
+			log.Fatalf("Invalid -pipelines: %v", err)
+		}
+		defaults := PipelineConfig{
+			Collection:    *mongoCollection,
+			Provider:      *provider,
+			APIBase:       *apiBase,
+			Model:         *model,
+			BatchSize:     *batchSize,
+			CheckInterval: *interval,
+		}
+		var services []*TranslationService
+		for _, p := range pipelines {
+			service, err := newPipelineService(p, defaults, encodedURI, *mongoDB, configureTranslator, opts...)
+			if err != nil {
+				log.Fatalf("Invalid -pipelines: %v", err)
+			}
+			services = append(services, service)
+		}
+		if err := RunPipelines(context.Background(), services); err != nil {
+			log.Fatalf("Service error: %v", err)
+		}
+		return
+	}
+
+	// Create service instance
+	translator, err := newTranslator(*provider, *apiBase, *model)
+	if err != nil {
+		log.Fatalf("Invalid -provider: %v", err)
+	}
+	members := []Translator{translator}
+	for _, spec := range ensembleWith {
+		member, err := newTranslator(parseTranslatorSpec(spec))
+		if err != nil {
+			log.Fatalf("Invalid -ensemble-with %q: %v", spec, err)
+		}
+		members = append(members, member)
+	}
+	for _, member := range members {
+		configureTranslator(member)
+	}
+	if len(members) > 1 {
+		translator, err = NewEnsembleTranslator(members, *ensembleScorer)
+		if err != nil {
+			log.Fatalf("Invalid ensemble: %v", err)
+		}
+	}
+
+	if *validateProvider {
+		// Only check the providers, MongoDB isn't needed
+		failed := false
+		for _, member := range members {
+			dt, ok := member.(*DeepSeekTranslator)
+			if !ok {
+				fmt.Printf("✅ %T needs no validation\n", member)
+				continue
+			}
+			if err := dt.ValidateProvider(context.Background()); err != nil {
+				fmt.Printf("❌ %s %s at %s: %v\n", dt.provider, dt.model, dt.baseURL, err)
+				failed = true
+				continue
+			}
+			fmt.Printf("✅ %s %s at %s is available\n", dt.provider, dt.model, dt.baseURL)
+		}
+		if failed {
+			log.Fatal("Provider validation failed")
+		}
+		return
+	}
+
+	service := NewTranslationService(encodedURI, *mongoDB, *mongoCollection, *interval, translator, opts...)
+
+	ctx := context.Background()
+
+	if *showStats {
+		// Only show statistics
+		err := service.ConnectMongoDB(ctx)
+		if err != nil {
+			log.Fatalf("Failed to connect to MongoDB: %v", err)
+		}
+		defer service.CloseMongoDB(ctx)
+
+		err = service.ShowStats(ctx, true)
+		if err != nil {
+			log.Fatalf("Error showing stats: %v", err)
+		}
+		return
+	}
+
+	if *exportPath != "" {
+		// Only export the translated products
+		var sinceTime time.Time
+		if *since != "" {
+			sinceTime, err = parseSince(*since)
+			if err != nil {
+				log.Fatalf("Invalid -since: %v", err)
+			}
+		}
+
+		err := service.ConnectMongoDB(ctx)
+		if err != nil {
+			log.Fatalf("Failed to connect to MongoDB: %v", err)
+		}
+		defer service.CloseMongoDB(ctx)
+
+		exported, err := service.ExportTranslated(ctx, *exportPath, sinceTime)
+		if err != nil {
+			log.Fatalf("Error exporting translations: %v", err)
+		}
+		log.Printf("Exported %d translated products to %s", exported, *exportPath)
+		return
+	}
+
+	if *cacheAgeReport {
+		// Only report the cache age distribution
+		err := service.ConnectMongoDB(ctx)
+		if err != nil {
+			log.Fatalf("Failed to connect to MongoDB: %v", err)
+		}
+		defer service.CloseMongoDB(ctx)
+
+		buckets, err := service.CacheAgeReport(ctx, time.Now())
+		if err != nil {
+			log.Fatalf("Error reporting cache age: %v", err)
+		}
+		PrintCacheAgeReport(buckets)
+		return
+	}
+
+	if *promoteReviewed {
+		// Only move approved translations live
+		err := service.ConnectMongoDB(ctx)
+		if err != nil {
+			log.Fatalf("Failed to connect to MongoDB: %v", err)
+		}
+		defer service.CloseMongoDB(ctx)
+
+		if _, err := service.PromoteReviewed(ctx); err != nil {
+			log.Fatalf("Error promoting reviewed translations: %v", err)
+		}
+		return
+	}
+
+	if *migrateFields {
+		// Only rename the legacy target fields
+		err := service.ConnectMongoDB(ctx)
+		if err != nil {
+			log.Fatalf("Failed to connect to MongoDB: %v", err)
+		}
+		defer service.CloseMongoDB(ctx)
+
+		migrated, err := service.MigrateFields(ctx)
+		if err != nil {
+			log.Fatalf("Error migrating fields: %v", err)
+		}
+		fmt.Printf("Migrated %d products\n", migrated)
+		return
+	}
+
+	if *findOrphans {
+		// Only compare the queue with the normalized collection
+		err := service.ConnectMongoDB(ctx)
+		if err != nil {
+			log.Fatalf("Failed to connect to MongoDB: %v", err)
+		}
+		defer service.CloseMongoDB(ctx)
+
+		orphans, err := service.FindOrphans(ctx, *deadLetterOrphan)
+		if err != nil {
+			log.Fatalf("Error finding orphans: %v", err)
+		}
+		service.PrintOrphans(orphans, *deadLetterOrphan)
+		return
+	}
+
+	if *simulateNorm {
+		// Only read the cache
+		err := service.ConnectMongoDB(ctx)
+		if err != nil {
+			log.Fatalf("Failed to connect to MongoDB: %v", err)
+		}
+		defer service.CloseMongoDB(ctx)
+
+		report, err := service.SimulateNormalization(ctx)
+		if err != nil {
+			log.Fatalf("Error simulating normalization: %v", err)
+		}
+		PrintNormalizationReport(report)
+		return
+	}
+
+	if *rehashCache {
+		// Only migrate the cache keys
+		err := service.ConnectMongoDB(ctx)
+		if err != nil {
+			log.Fatalf("Failed to connect to MongoDB: %v", err)
+		}
+		defer service.CloseMongoDB(ctx)
+
+		if _, _, err := service.RehashCache(ctx); err != nil {
+			log.Fatalf("Error rehashing cache: %v", err)
+		}
+		return
+	}
+
+	if *plan {
+		// Only project the work, nothing is translated
+		err := service.ConnectMongoDB(ctx)
+		if err != nil {
+			log.Fatalf("Failed to connect to MongoDB: %v", err)
+		}
+		defer service.CloseMongoDB(ctx)
+
+		p, err := service.PlanProcessing(ctx, *planLimit)
+		if err != nil {
+			log.Fatalf("Error planning processing: %v", err)
+		}
+		service.PrintPlan(p)
+		return
+	}
+
+	if *auditConsistency {
+		// Only compare stored translations with the cache
+		err := service.ConnectMongoDB(ctx)
+		if err != nil {
+			log.Fatalf("Failed to connect to MongoDB: %v", err)
+		}
+		defer service.CloseMongoDB(ctx)
+
+		entries, checked, err := service.AuditConsistency(ctx, *auditLimit)
+		if err != nil {
+			log.Fatalf("Error auditing translations: %v", err)
+		}
+		PrintAuditReport(entries, checked)
+		return
+	}
+
+	if *debugHash != "" {
+		// Only replay one product
+		err := service.ConnectMongoDB(ctx)
+		if err != nil {
+			log.Fatalf("Failed to connect to MongoDB: %v", err)
+		}
+		defer service.CloseMongoDB(ctx)
+
+		err = service.DebugHash(ctx, *debugHash, *commit)
+		if err != nil {
+			log.Fatalf("Error debugging %s: %v", *debugHash, err)
+		}
+		return
+	}
+
+	if *diff {
+		// Only compare fresh translations with the stored ones
+		err := service.ConnectMongoDB(ctx)
+		if err != nil {
+			log.Fatalf("Failed to connect to MongoDB: %v", err)
+		}
+		defer service.CloseMongoDB(ctx)
+
+		entries, err := service.DiffTranslations(ctx, *diffLimit)
+		if err != nil {
+			log.Fatalf("Error diffing translations: %v", err)
+		}
+		PrintDiffReport(entries)
+		return
+	}
+
+	fmt.Println("Unified Translation Service Configuration:")
+	fmt.Printf("  Source: toys_translation_pending -> %s\n", *mongoCollection)
+	fmt.Printf("  Fields: %v\n", service.fieldsToTranslate)
+	if len(noCacheFields) > 0 {
+		fmt.Printf("  Uncached fields: %v\n", []string(noCacheFields))
+	}
+	if *maxCost > 0 {
+		fmt.Printf("  Spend budget: $%.2f\n", *maxCost)
+	}
+	if *subBatchSize > 0 {
+		fmt.Printf("  Sub-batches: %d texts, %d concurrent\n", *subBatchSize, *concurrency)
+	}
+	if len(fieldSubBatch) > 0 || len(fieldConcurrency) > 0 {
+		fmt.Printf("  Per-field sub-batches: %v, concurrency: %v\n", fieldSubBatch, fieldConcurrency)
+	}
+	if *batchTokens > 0 {
+		fmt.Printf("  Batch token budget: %d\n", *batchTokens)
+	}
+	fmt.Println()
+
+	if *once {
+		err = service.RunOnce(ctx)
+		if err != nil {
+			log.Fatalf("Service error: %v", err)
+		}
+		return
+	}
+
+	// Run service
+	err = service.Run(ctx)
+	if err != nil {
+		log.Fatalf("Service error: %v", err)
+	}
+
+	log.Println("Shutting down Translation Service...")
+}
@@ -0,0 +1,36 @@
+package translation
+
+import (
+	"fmt"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// parseWriteConcern parses a write concern given as "majority", a tag set
+// name or a number of nodes. An empty value keeps the driver default.
+func parseWriteConcern(value string) (*writeconcern.WriteConcern, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if n, err := strconv.Atoi(value); err == nil {
+		if n < 0 {
+			return nil, fmt.Errorf("write concern can't be negative, got %d", n)
+		}
+		return &writeconcern.WriteConcern{W: n}, nil
+	}
+	return &writeconcern.WriteConcern{W: value}, nil
+}
+
+// parseReadConcern parses a read concern level. An empty value keeps the
+// driver default.
+func parseReadConcern(level string) (*readconcern.ReadConcern, error) {
+	switch level {
+	case "":
+		return nil, nil
+	case "local", "available", "majority", "linearizable", "snapshot":
+		return &readconcern.ReadConcern{Level: level}, nil
+	}
+	return nil, fmt.Errorf("unknown read concern %q (expected local, available, majority, linearizable or snapshot)", level)
+}
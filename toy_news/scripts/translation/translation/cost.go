@@ -0,0 +1,112 @@
+package translation
+
+import (
+	"errors"
+	"sync"
+	"unicode"
+)
+
+// ErrBudgetExceeded is returned instead of calling the API once the
+// estimated spend has reached the configured budget
+var ErrBudgetExceeded = errors.New("API spend budget exhausted")
+
+// Usage represents the token usage reported by the API
+type Usage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+}
+
+// usageTracker accumulates token usage and the estimated spend. An
+// ensemble's tracker holds no usage of its own but sums its members', and
+// the members check their budget against that total.
+type usageTracker struct {
+	mu               sync.Mutex
+	promptTokens     int64
+	completionTokens int64
+
+	inputPrice  float64 // USD per million prompt tokens
+	outputPrice float64 // USD per million completion tokens
+	maxCost     float64 // USD, 0 means unlimited
+
+	members []*usageTracker // trackers summed by an ensemble's tracker
+	total   *usageTracker   // the ensemble's tracker the budget is checked against
+}
+
+// newTotalTracker creates the tracker summing members with the budget of the
+// first one, which becomes the budget of every member
+func newTotalTracker(members []*usageTracker) *usageTracker {
+	total := &usageTracker{members: members, maxCost: members[0].maxCost}
+	for _, member := range members {
+		member.total = total
+	}
+	return total
+}
+
+// add records the usage of one API call
+func (u *usageTracker) add(usage Usage) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.promptTokens += usage.PromptTokens
+	u.completionTokens += usage.CompletionTokens
+}
+
+// tokens returns the accumulated prompt and completion tokens. A nil tracker
+// belongs to a translator without API spend and reports nothing.
+func (u *usageTracker) tokens() (int64, int64) {
+	if u == nil {
+		return 0, 0
+	}
+	if len(u.members) > 0 {
+		var prompt, completion int64
+		for _, member := range u.members {
+			p, c := member.tokens()
+			prompt, completion = prompt+p, completion+c
+		}
+		return prompt, completion
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.promptTokens, u.completionTokens
+}
+
+// cost returns the estimated spend so far in USD, priced per member for an
+// ensemble's tracker
+func (u *usageTracker) cost() float64 {
+	if u == nil {
+		return 0
+	}
+	if len(u.members) > 0 {
+		var spend float64
+		for _, member := range u.members {
+			spend += member.cost()
+		}
+		return spend
+	}
+	prompt, completion := u.tokens()
+	return float64(prompt)*u.inputPrice/1e6 + float64(completion)*u.outputPrice/1e6
+}
+
+// exhausted reports whether the spend budget has been reached, by the whole
+// ensemble for a member's tracker
+func (u *usageTracker) exhausted() bool {
+	if u != nil && u.total != nil {
+		return u.total.exhausted()
+	}
+	return u != nil && u.maxCost > 0 && u.cost() >= u.maxCost
+}
+
+// estimateTokens roughly estimates the prompt tokens of a text without a
+// tokenizer: about one token per CJK character and one per four other
+// characters
+func estimateTokens(text string) int {
+	cjk, other := 0, 0
+	for _, r := range text {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+	return cjk + (other+3)/4
+}
@@ -0,0 +1,45 @@
+package translation
+
+import (
+	"math"
+	"testing"
+)
+
+func TestUsageTrackerBudget(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxCost       float64
+		usage         []Usage
+		wantCost      float64
+		wantExhausted bool
+	}{
+		{"unlimited", 0, []Usage{{PromptTokens: 10_000_000}}, 2.7, false},
+		{"under budget", 1, []Usage{{PromptTokens: 1_000_000}, {CompletionTokens: 500_000}}, 0.82, false},
+		{"budget reached", 1, []Usage{{PromptTokens: 1_000_000, CompletionTokens: 700_000}}, 1.04, true},
+		{"no usage", 1, nil, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &usageTracker{inputPrice: 0.27, outputPrice: 1.10, maxCost: tt.maxCost}
+			for _, usage := range tt.usage {
+				u.add(usage)
+			}
+			if got := u.cost(); math.Abs(got-tt.wantCost) > 1e-9 {
+				t.Errorf("cost() = %v, want %v", got, tt.wantCost)
+			}
+			if got := u.exhausted(); got != tt.wantExhausted {
+				t.Errorf("exhausted() = %v, want %v", got, tt.wantExhausted)
+			}
+		})
+	}
+}
+
+func TestNilUsageTracker(t *testing.T) {
+	var u *usageTracker
+	if prompt, completion := u.tokens(); prompt != 0 || completion != 0 {
+		t.Errorf("tokens() = %d, %d, want 0, 0", prompt, completion)
+	}
+	if u.cost() != 0 || u.exhausted() {
+		t.Error("a nil tracker should report no spend")
+	}
+}
@@ -0,0 +1,147 @@
+package translation
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// deadLetterCollectionName holds pending items that can never be processed,
+// with the reason they were set aside
+const deadLetterCollectionName = "toys_translation_dead_letter"
+
+// deadLetter moves the pending documents matching filter to the dead-letter
+// collection, recording reason and the time they were moved. It returns the
+// number of documents moved.
+func (ts *TranslationService) deadLetter(ctx context.Context, filter bson.M, reason string) (int, error) {
+	cursor, err := ts.pendingCollection.Find(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	var docs []bson.M
+	err = cursor.All(ctx, &docs)
+	if err != nil {
+		return 0, err
+	}
+	if len(docs) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	var models []mongo.WriteModel
+	var ids []interface{}
+	for _, doc := range docs {
+		doc["deadLetterReason"] = reason
+		doc["deadLetteredAt"] = now
+		// Upserting by _id keeps a retried move from failing on duplicates
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": doc["_id"]}).
+			SetReplacement(doc).
+			SetUpsert(true))
+		ids = append(ids, doc["_id"])
+	}
+	_, err = ts.bulkWrite(ctx, ts.deadLetterCollection, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return 0, fmt.Errorf("failed to dead-letter pending items: %w", err)
+	}
+
+	_, err = ts.deleteMany(ctx, ts.pendingCollection, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove dead-lettered pending items: %w", err)
+	}
+
+	log.Printf("☠️  %d 个待翻译项目已移入 %s: %s", len(docs), deadLetterCollectionName, reason)
+	return len(docs), nil
+}
+
+// missingProducts returns the product hashes of ops that have no document in
+// the normalized collection, so their updates matched nothing
+func (ts *TranslationService) missingProducts(ctx context.Context, ops []UpdateOperation) (map[string]bool, error) {
+	missing := make(map[string]bool, len(ops))
+	var hashes []string
+	for _, op := range ops {
+		missing[op.ProductHash] = true
+		hashes = append(hashes, op.ProductHash)
+	}
+
+	filter := bson.M{"product_hash": bson.M{"$in": hashes}}
+	opts := options.Find().SetProjection(bson.M{"product_hash": 1})
+	cursor, err := ts.normalizedCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var found []NormalizedItem
+	err = cursor.All(ctx, &found)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range found {
+		delete(missing, item.ProductHash)
+	}
+	return missing, nil
+}
+
+// dropHashlessItems dead-letters the pending items without a product_hash,
+// which the normalized collection can't be updated by, and returns the rest
+func (ts *TranslationService) dropHashlessItems(ctx context.Context, items []PendingItem) ([]PendingItem, error) {
+	var kept []PendingItem
+	var ids []primitive.ObjectID
+	for _, item := range items {
+		if item.ProductHash == "" {
+			log.Printf("⚠️  待翻译项目 %s 没有 product_hash，无法写回翻译", item.ID.Hex())
+			ids = append(ids, item.ID)
+		} else {
+			kept = append(kept, item)
+		}
+	}
+	if len(ids) == 0 {
+		return items, nil
+	}
+
+	_, err := ts.deadLetter(ctx, bson.M{"_id": bson.M{"$in": ids}}, "pending item has no product_hash")
+	if err != nil {
+		return nil, err
+	}
+	return kept, nil
+}
+
+// decodePending decodes the current document of a pending cursor. A document
+// that doesn't decode is logged and its _id added to malformed instead, so one
+// bad record can't stall the queue.
+func decodePending(cursor *mongo.Cursor, malformed *[]interface{}) (PendingItem, bool) {
+	var item PendingItem
+	err := cursor.Decode(&item)
+	if err == nil {
+		return item, true
+	}
+
+	var doc struct {
+		ID interface{} `bson:"_id"`
+	}
+	if idErr := bson.Unmarshal(cursor.Current, &doc); idErr != nil || doc.ID == nil {
+		log.Printf("⚠️ 无法解码待翻译项目且缺少 _id，跳过: %v", err)
+		return item, false
+	}
+	log.Printf("⚠️ 无法解码待翻译项目 %v，跳过: %v", doc.ID, err)
+	*malformed = append(*malformed, doc.ID)
+	return item, false
+}
+
+// deadLetterMalformed moves the pending documents that failed to decode to the
+// dead-letter collection. Failures are only logged, the documents are skipped
+// again by the next cycle.
+func (ts *TranslationService) deadLetterMalformed(ctx context.Context, ids []interface{}) {
+	if len(ids) == 0 {
+		return
+	}
+	_, err := ts.deadLetter(ctx, bson.M{"_id": bson.M{"$in": ids}}, "malformed pending document")
+	if err != nil {
+		log.Printf("Error dead-lettering malformed pending items: %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package translation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// DebugHash replays the translation of one product verbosely. The product is
+// read from the pending collection, or from the normalized collection when it
+// isn't pending. Without commit nothing is written, not even the cache; with
+// commit the product is processed like any pending item.
+func (ts *TranslationService) DebugHash(ctx context.Context, productHash string, commit bool) error {
+	var item PendingItem
+	err := ts.pendingCollection.FindOne(ctx, bson.M{"product_hash": productHash}).Decode(&item)
+	source := "pending"
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		source = ts.mongoCollection
+		err = ts.normalizedCollection.FindOne(ctx, bson.M{"product_hash": productHash}).Decode(&item)
+	}
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return fmt.Errorf("product %s is neither pending nor in %s", productHash, ts.mongoCollection)
+	}
+	if err != nil {
+		return fmt.Errorf("error reading product %s: %w", productHash, err)
+	}
+
+	fmt.Printf("🔍 Debugging %s (from %s)\n", productHash, source)
+	original := TranslatedItem{PendingItem: item}
+	for _, field := range ts.fieldsToTranslate {
+		text, _ := original.fieldValues(field)
+		fmt.Printf("  %s: %s\n", field, text)
+	}
+
+	if commit {
+		processed, err := ts.processBatch(ctx, []PendingItem{item})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Committed: %d item(s) processed\n", processed)
+		return nil
+	}
+
+	ts.readOnly = true
+	defer func() { ts.readOnly = false }()
+
+	translated, err := ts.TranslateWithCache(ctx, []PendingItem{item})
+	if err != nil {
+		return err
+	}
+	fmt.Println("Parsed result (not written, pass -commit to store it):")
+	for _, field := range ts.fieldsToTranslate {
+		_, translation := translated[0].fieldValues(field)
+		fmt.Printf("  %s: %s\n", ts.targetKey(field), translation)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package translation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NormalizedItem represents the translatable part of a normalized product
+type NormalizedItem struct {
+	ProductHash   string `bson:"product_hash"`
+	Name          string `bson:"name,omitempty"`
+	Description   string `bson:"description,omitempty"`
+	NameCN        string `bson:"nameCN,omitempty"`
+	DescriptionCN string `bson:"descriptionCN,omitempty"`
+
+	Extra bson.M `bson:",inline"` // the other fields, such as localized maps
+}
+
+// DiffEntry represents the comparison of a fresh translation with the stored one
+type DiffEntry struct {
+	ProductHash string
+	Field       string
+	Source      string
+	Existing    string
+	Translated  string
+	Status      string // "new", "changed" or "unchanged"
+}
+
+// classifyDiff compares a fresh translation with the stored value
+func classifyDiff(existing, translated string) string {
+	switch {
+	case existing == "":
+		return "new"
+	case existing == translated:
+		return "unchanged"
+	default:
+		return "changed"
+	}
+}
+
+// DiffTranslations re-translates the source fields of up to limit normalized
+// products and compares the results with the stored translations. The cache
+// is bypassed and nothing is written.
+func (ts *TranslationService) DiffTranslations(ctx context.Context, limit int) ([]DiffEntry, error) {
+	var sourceFilters []bson.M
+	for _, field := range ts.fieldsToTranslate {
+		sourceFilters = append(sourceFilters, bson.M{field: bson.M{"$nin": bson.A{nil, ""}}})
+	}
+
+	opts := options.Find().SetLimit(int64(limit))
+	cursor, err := ts.normalizedCollection.Find(ctx, bson.M{"$or": sourceFilters}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding normalized items: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var items []NormalizedItem
+	err = cursor.All(ctx, &items)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding normalized items: %w", err)
+	}
+
+	var entries []DiffEntry
+	for _, field := range ts.fieldsToTranslate {
+		// Collect unique source texts so each one is translated once
+		var texts []string
+		seen := make(map[string]bool)
+		for _, item := range items {
+			source, _ := ts.itemField(item, field)
+			if source != "" && !seen[source] {
+				seen[source] = true
+				texts = append(texts, source)
+			}
+		}
+		if len(texts) == 0 {
+			continue
+		}
+
+		translations, err := ts.translateTexts(ctx, field, texts)
+		if err != nil && !errors.Is(err, ErrCountMismatch) {
+			return nil, fmt.Errorf("error translating %s texts: %w", field, err)
+		}
+
+		translated := make(map[string]string)
+		missing := untranslatedIndices(err)
+		for i, text := range texts {
+			if i < len(translations) && !missing[i] {
+				translated[text] = ts.normalizeOutput(translations[i])
+			}
+		}
+
+		for _, item := range items {
+			source, existing := ts.itemField(item, field)
+			if source == "" || translated[source] == "" {
+				continue
+			}
+			entries = append(entries, DiffEntry{
+				ProductHash: item.ProductHash,
+				Field:       field,
+				Source:      source,
+				Existing:    existing,
+				Translated:  translated[source],
+				Status:      classifyDiff(existing, translated[source]),
+			})
+		}
+	}
+
+	return entries, nil
+}
+
+// PrintDiffReport prints the diff entries followed by a summary
+func PrintDiffReport(entries []DiffEntry) {
+	counts := make(map[string]int)
+	for _, entry := range entries {
+		counts[entry.Status]++
+		if entry.Status == "unchanged" {
+			continue
+		}
+
+		fmt.Printf("[%s] %s %s\n", entry.Status, entry.ProductHash, entry.Field)
+		fmt.Printf("  原文: %s\n", entry.Source)
+		if entry.Existing != "" {
+			fmt.Printf("  - %s\n", entry.Existing)
+		}
+		fmt.Printf("  + %s\n", entry.Translated)
+	}
+
+	fmt.Printf("Diff summary: %d changed, %d unchanged, %d new\n",
+		counts["changed"], counts["unchanged"], counts["new"])
+}
@@ -0,0 +1,174 @@
+package translation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ensembleScorer rates a translation of source; the highest score wins
+type ensembleScorer func(source, translation string) float64
+
+// ensembleScorers are the scorers -ensemble-scorer can name
+var ensembleScorers = map[string]ensembleScorer{
+	"length": lengthScore,
+}
+
+// lengthScore prefers translations whose length is closest to the source's.
+// Chinese renders Japanese in roughly as many characters, while dropped
+// content or rambling explanations change the length markedly. A translation
+// equal to its source scores lowest.
+func lengthScore(source, translation string) float64 {
+	if translation == source {
+		return math.Inf(-1)
+	}
+	sourceLen := float64(utf8.RuneCountInString(source))
+	if sourceLen == 0 {
+		return 0
+	}
+	ratio := float64(utf8.RuneCountInString(translation)) / sourceLen
+	return -math.Abs(math.Log(ratio))
+}
+
+// EnsembleTranslator asks several translators for every text and keeps the
+// translation its scorer rates best. Each text costs one call per member, so
+// it is meant for quality-critical runs only.
+type EnsembleTranslator struct {
+	members []Translator
+	scorer  ensembleScorer
+	usage   *usageTracker // total spend of the members, nil when none tracks it
+}
+
+// NewEnsembleTranslator creates an ensemble of members choosing by the named
+// scorer. The spend of all members counts against the first member's budget.
+func NewEnsembleTranslator(members []Translator, scorer string) (*EnsembleTranslator, error) {
+	if len(members) < 2 {
+		return nil, errors.New("an ensemble needs at least two translators")
+	}
+	score, ok := ensembleScorers[scorer]
+	if !ok {
+		return nil, fmt.Errorf("unknown ensemble scorer %q (expected length)", scorer)
+	}
+	et := &EnsembleTranslator{members: members, scorer: score}
+	var trackers []*usageTracker
+	for _, member := range members {
+		if tracking, ok := member.(usageTracking); ok && tracking.UsageTracker() != nil {
+			trackers = append(trackers, tracking.UsageTracker())
+		}
+	}
+	if len(trackers) > 0 {
+		et.usage = newTotalTracker(trackers)
+	}
+	return et, nil
+}
+
+// TranslateFieldTexts translates texts with every member in parallel and
+// picks the best scored translation per text. A text no member translated
+// keeps its source text and is reported in a *CountMismatchError; when every
+// member failed, the first member's error is returned.
+func (et *EnsembleTranslator) TranslateFieldTexts(ctx context.Context, field string, texts []string) ([]string, error) {
+	results := make([][]string, len(et.members))
+	missing := make([]map[int]bool, len(et.members))
+	errs := make([]error, len(et.members))
+
+	var wg sync.WaitGroup
+	for i, member := range et.members {
+		wg.Add(1)
+		go func(i int, member Translator) {
+			defer wg.Done()
+			results[i], errs[i] = member.TranslateFieldTexts(ctx, field, texts)
+			missing[i] = untranslatedIndices(errs[i])
+		}(i, member)
+	}
+	wg.Wait()
+
+	translations := make([]string, len(texts))
+	mismatch := &CountMismatchError{Want: len(texts)}
+	failed := 0
+	for i, err := range errs {
+		if err != nil && !errors.Is(err, ErrCountMismatch) {
+			failed++
+			results[i] = nil
+		}
+	}
+	if failed == len(et.members) {
+		return texts, errs[0]
+	}
+
+	for t, text := range texts {
+		best, bestScore := "", math.Inf(-1)
+		found := false
+		for m := range et.members {
+			if results[m] == nil || t >= len(results[m]) || missing[m][t] {
+				continue
+			}
+			if score := et.scorer(text, results[m][t]); !found || score > bestScore {
+				best, bestScore, found = results[m][t], score, true
+			}
+		}
+		if !found {
+			translations[t] = text
+			mismatch.Missing = append(mismatch.Missing, t)
+			continue
+		}
+		translations[t] = best
+		mismatch.Got++
+	}
+
+	if len(mismatch.Missing) > 0 {
+		return translations, mismatch
+	}
+	return translations, nil
+}
+
+// Provenance names every member, since the winner varies by text
+func (et *EnsembleTranslator) Provenance(field string) Provenance {
+	var providers, models, versions []string
+	for _, member := range et.members {
+		p := member.Provenance(field)
+		providers = append(providers, p.Provider)
+		models = append(models, p.Model)
+		versions = append(versions, p.PromptVersion)
+	}
+	return Provenance{
+		Provider:      "ensemble:" + strings.Join(providers, "+"),
+		Model:         strings.Join(models, "+"),
+		PromptVersion: strings.Join(versions, "+"),
+	}
+}
+
+// UsageTracker returns the tracker of the members' total spend, if any
+func (et *EnsembleTranslator) UsageTracker() *usageTracker {
+	return et.usage
+}
+
+// ConsecutiveErrors returns the failed API call streak of the primary member
+func (et *EnsembleTranslator) ConsecutiveErrors() int64 {
+	if tracking, ok := et.members[0].(errorStreakTracking); ok {
+		return tracking.ConsecutiveErrors()
+	}
+	return 0
+}
+
+// APICalls returns the API calls made by all members
+func (et *EnsembleTranslator) APICalls() int64 {
+	var calls int64
+	for _, member := range et.members {
+		if counting, ok := member.(apiCallCounting); ok {
+			calls += counting.APICalls()
+		}
+	}
+	return calls
+}
+
+// parseTranslatorSpec splits an -ensemble-with value of the form
+// provider[:model][@apiBase] into the arguments of newTranslator
+func parseTranslatorSpec(spec string) (provider, apiBase, model string) {
+	spec, apiBase, _ = strings.Cut(spec, "@")
+	provider, model, _ = strings.Cut(spec, ":")
+	return provider, apiBase, model
+}
@@ -0,0 +1,80 @@
+package translation
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestEnsemblePicksBestScore(t *testing.T) {
+	// Both texts are four characters long, the closest length wins
+	verbose := fakeTranslator{answers: map[string]string{"ガンダム": "高达机动战士模型", "赤い彗星": "红色"}}
+	terse := fakeTranslator{answers: map[string]string{"ガンダム": "高达", "赤い彗星": "红色彗星"}}
+	failing := fakeTranslator{err: errors.New("unavailable")}
+
+	tests := []struct {
+		name    string
+		members []Translator
+		want    []string
+		wantErr bool
+	}{
+		{"closest length per text", []Translator{verbose, terse}, []string{"高达机动战士模型", "红色彗星"}, false},
+		{"failed member ignored", []Translator{failing, terse}, []string{"高达", "红色彗星"}, false},
+		{"all members failed", []Translator{failing, failing}, []string{"ガンダム", "赤い彗星"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			et, err := NewEnsembleTranslator(tt.members, "length")
+			if err != nil {
+				t.Fatalf("NewEnsembleTranslator: %v", err)
+			}
+			got, err := et.TranslateFieldTexts(context.Background(), "name", []string{"ガンダム", "赤い彗星"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("translations = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLengthScoreRejectsUntranslated(t *testing.T) {
+	if lengthScore("ガンダム", "ガンダム") >= lengthScore("ガンダム", "高达机动战士模型") {
+		t.Error("an untranslated text should score below any translation")
+	}
+}
+
+func TestEnsembleSharesSpendBudget(t *testing.T) {
+	primary := &usageTracker{inputPrice: 1, maxCost: 1}
+	secondary := &usageTracker{inputPrice: 2, maxCost: 1}
+	et, err := NewEnsembleTranslator([]Translator{
+		trackedTranslator{usage: primary},
+		trackedTranslator{usage: secondary},
+		fakeTranslator{},
+	}, "length")
+	if err != nil {
+		t.Fatalf("NewEnsembleTranslator: %v", err)
+	}
+
+	primary.add(Usage{PromptTokens: 300_000})
+	secondary.add(Usage{PromptTokens: 200_000})
+	total := et.UsageTracker()
+	if prompt, _ := total.tokens(); prompt != 500_000 {
+		t.Errorf("total prompt tokens = %d, want 500000", prompt)
+	}
+	if got := total.cost(); math.Abs(got-0.7) > 1e-9 {
+		t.Errorf("total cost = %v, want 0.7", got)
+	}
+	if primary.exhausted() || total.exhausted() {
+		t.Fatal("budget exhausted too early")
+	}
+
+	// Neither member reached the budget on its own, together they did
+	secondary.add(Usage{PromptTokens: 200_000})
+	if !total.exhausted() || !primary.exhausted() || !secondary.exhausted() {
+		t.Error("every member should stop once the total reached the budget")
+	}
+}
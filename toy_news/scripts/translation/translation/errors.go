@@ -0,0 +1,120 @@
+package translation
+
+import (
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Sentinel errors identifying where a failure came from. The concrete error
+// types below match them with errors.Is and carry the details for errors.As.
+var (
+	ErrTranslationAPI = errors.New("translation API error")
+	ErrCacheRead      = errors.New("translation cache read error")
+	ErrCacheWrite     = errors.New("translation cache write error")
+	ErrCountMismatch  = errors.New("translation count mismatch")
+	ErrTruncated      = errors.New("translation response truncated at the output token limit")
+	ErrEmptyChoices   = errors.New("no choices in API response")
+)
+
+// APIError describes a failed translation API call
+type APIError struct {
+	StatusCode int    // HTTP status, 0 when no response was received
+	Body       string // response body, if any
+	Err        error  // underlying error, if any
+}
+
+func (e *APIError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%v: %v", ErrTranslationAPI, e.Err)
+	}
+	return fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+func (e *APIError) Unwrap() error { return e.Err }
+
+func (e *APIError) Is(target error) bool { return target == ErrTranslationAPI }
+
+// APIErrorBody is the error object an API may return instead of choices
+type APIErrorBody struct {
+	Message string `json:"message"`
+	Type    string `json:"type,omitempty"`
+}
+
+// CacheError describes a failed read or write of the translation cache
+type CacheError struct {
+	Op       string // "read" or "write"
+	TextHash string
+	Err      error
+}
+
+func (e *CacheError) Error() string {
+	return fmt.Sprintf("translation cache %s failed for %s: %v", e.Op, e.TextHash, e.Err)
+}
+
+func (e *CacheError) Unwrap() error { return e.Err }
+
+func (e *CacheError) Is(target error) bool {
+	return (target == ErrCacheRead && e.Op == "read") || (target == ErrCacheWrite && e.Op == "write")
+}
+
+// unauthorizedCode is the MongoDB error code of an operation the user lacks
+// the privileges for
+const unauthorizedCode = 13
+
+// isUnauthorized reports whether a MongoDB operation was refused for lack of
+// privileges, which retrying won't fix
+func isUnauthorized(err error) bool {
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr) && serverErr.HasErrorCode(unauthorizedCode)
+}
+
+// CountMismatchError is returned when the API answers with a different number
+// of translations than texts were sent. The translations returned alongside
+// it have already been truncated or padded to the expected length; Missing
+// lists the indices padded with their source text, which are not
+// translations. Err is set when texts are missing because part of a split
+// batch failed.
+type CountMismatchError struct {
+	Got     int
+	Want    int
+	Missing []int
+	Err     error
+}
+
+func (e *CountMismatchError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("got %d translations for %d texts: %v", e.Got, e.Want, e.Err)
+	}
+	return fmt.Sprintf("got %d translations for %d texts", e.Got, e.Want)
+}
+
+func (e *CountMismatchError) Unwrap() error { return e.Err }
+
+func (e *CountMismatchError) Is(target error) bool { return target == ErrCountMismatch }
+
+// errLeftOut describes texts the API answered without a translation for
+var errLeftOut = errors.New("left out of the API response")
+
+// missingError returns why the texts a batch left out got no translation
+func missingError(err error) error {
+	var mismatch *CountMismatchError
+	if errors.As(err, &mismatch) && mismatch.Err != nil {
+		return mismatch.Err
+	}
+	return errLeftOut
+}
+
+// untranslatedIndices returns the indices of a batch's translations that are
+// placeholders for texts the API left out
+func untranslatedIndices(err error) map[int]bool {
+	missing := make(map[int]bool)
+	var mismatch *CountMismatchError
+	if errors.As(err, &mismatch) {
+		for _, index := range mismatch.Missing {
+			missing[index] = true
+		}
+	}
+	return missing
+}
@@ -0,0 +1,68 @@
+package translation
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestAPIErrorFromFailedCall(t *testing.T) {
+	dt, _ := newFakeAPI(t, func(call int, texts []string) (int, string, string) {
+		return http.StatusBadRequest, "", ""
+	})
+	dt.maxRetries = 0
+
+	_, err := dt.TranslateTexts(context.Background(), []string{"赤", "青"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want an *APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", apiErr.StatusCode)
+	}
+	if !errors.Is(err, ErrTranslationAPI) || errors.Is(err, ErrCacheWrite) {
+		t.Errorf("err = %v, want it to be ErrTranslationAPI only", err)
+	}
+}
+
+func TestCacheErrorIs(t *testing.T) {
+	cause := errors.New("connection reset")
+	tests := []struct {
+		op        string
+		wantRead  bool
+		wantWrite bool
+	}{
+		{"read", true, false},
+		{"write", false, true},
+	}
+	for _, tt := range tests {
+		var err error = &CacheError{Op: tt.op, TextHash: "abc", Err: cause}
+		if errors.Is(err, ErrCacheRead) != tt.wantRead || errors.Is(err, ErrCacheWrite) != tt.wantWrite {
+			t.Errorf("%s error: Is(read) %v, Is(write) %v", tt.op, errors.Is(err, ErrCacheRead), errors.Is(err, ErrCacheWrite))
+		}
+		if !errors.Is(err, cause) {
+			t.Errorf("%s error doesn't unwrap to its cause", tt.op)
+		}
+		var cacheErr *CacheError
+		if !errors.As(err, &cacheErr) || cacheErr.TextHash != "abc" {
+			t.Errorf("errors.As = %+v", cacheErr)
+		}
+	}
+}
+
+func TestCountMismatchErrorFromShortAnswer(t *testing.T) {
+	dt, _ := newFakeAPI(t, func(call int, texts []string) (int, string, string) {
+		return http.StatusOK, "1. 译:" + texts[0], "stop"
+	})
+	dt.maxRetries = 0
+
+	_, err := dt.TranslateTexts(context.Background(), []string{"赤", "青", "黄"})
+	var mismatch *CountMismatchError
+	if !errors.As(err, &mismatch) || !errors.Is(err, ErrCountMismatch) {
+		t.Fatalf("err = %v, want a *CountMismatchError", err)
+	}
+	if mismatch.Want != 3 {
+		t.Errorf("mismatch = %+v, want 3 texts expected", mismatch)
+	}
+}
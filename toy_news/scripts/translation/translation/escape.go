@@ -0,0 +1,78 @@
+package translation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// Escaping modes for translations written to the normalized collection
+const (
+	EscapeNone = "none"
+	EscapeHTML = "html"
+	EscapeJSON = "json"
+)
+
+// parseOutputEscape validates an output escaping mode
+func parseOutputEscape(mode string) (string, error) {
+	switch mode {
+	case EscapeNone, EscapeHTML, EscapeJSON:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown output escaping %q (expected none, html or json)", mode)
+}
+
+// escapeOutput escapes a translation for downstream stores. The cache keeps
+// the unescaped text, so changing the mode never requires re-translating.
+func escapeOutput(text, mode string) string {
+	switch mode {
+	case EscapeHTML:
+		return html.EscapeString(text)
+	case EscapeJSON:
+		// The contents of a JSON string literal, without the quotes
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(text); err != nil {
+			return text
+		}
+		quoted := strings.TrimSuffix(buf.String(), "\n")
+		return quoted[1 : len(quoted)-1]
+	}
+	return text
+}
+
+// defaultNewlineEscape stands in for newlines inside texts sent as a numbered
+// list, where a line break would look like the end of the item
+const defaultNewlineEscape = "<br>"
+
+// escapeNewlines replaces the newlines of texts with marker. It returns the
+// texts to send and which of them were changed, so only those are restored.
+func escapeNewlines(texts []string, marker string) ([]string, map[int]bool) {
+	escaped := make(map[int]bool)
+	if marker == "" {
+		return texts, escaped
+	}
+
+	sent := make([]string, len(texts))
+	for i, text := range texts {
+		if strings.Contains(text, "\n") {
+			text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\n", marker)
+			escaped[i] = true
+		}
+		sent[i] = text
+	}
+	return sent, escaped
+}
+
+// restoreNewlines turns the markers of the escaped translations back into
+// newlines
+func restoreNewlines(translations []string, escaped map[int]bool, marker string) {
+	for i := range translations {
+		if escaped[i] {
+			translations[i] = strings.ReplaceAll(translations[i], marker, "\n")
+		}
+	}
+}
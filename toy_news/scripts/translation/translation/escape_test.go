@@ -0,0 +1,48 @@
+package translation
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEscapeOutput(t *testing.T) {
+	text := `<b>限定</b> "A&B" 版`
+	tests := []struct {
+		mode string
+		want string
+	}{
+		{EscapeNone, text},
+		{EscapeHTML, "&lt;b&gt;限定&lt;/b&gt; &#34;A&amp;B&#34; 版"},
+		{EscapeJSON, `<b>限定</b> \"A&B\" 版`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			if got := escapeOutput(text, tt.mode); got != tt.want {
+				t.Errorf("escapeOutput(%q) = %q, want %q", tt.mode, got, tt.want)
+			}
+		})
+	}
+	if _, err := parseOutputEscape("xml"); err == nil {
+		t.Error("parseOutputEscape should reject an unknown mode")
+	}
+}
+
+func TestTranslateTextsWithNewlines(t *testing.T) {
+	dt, api := newFakeAPI(t, nil)
+	texts := []string{"全高約180mm\n付属品:\r\n台座", "ガンダム", "一行目\n二行目"}
+	got, err := dt.TranslateTexts(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("TranslateTexts: %v", err)
+	}
+	want := []string{"译:全高約180mm\n付属品:\n台座", "译:ガンダム", "译:一行目\n二行目"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("translations = %q, want %q", got, want)
+	}
+	for _, sent := range api.calls[0] {
+		if strings.Contains(sent, "\n") {
+			t.Errorf("sent %q, want its newlines escaped", sent)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package translation
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// evictionSort orders cache entries by how little keeping them is worth:
+// least used first, then least recently updated
+var evictionSort = bson.D{{Key: "usage_count", Value: 1}, {Key: "updated_at", Value: 1}}
+
+// evictionChunk is how many cache entries one delete removes at most
+const evictionChunk = 1000
+
+// EvictCache deletes the least valuable cache entries until at most
+// cacheMaxEntries remain and returns how many were deleted
+func (ts *TranslationService) EvictCache(ctx context.Context) (int64, error) {
+	if ts.cacheMaxEntries <= 0 {
+		return 0, nil
+	}
+	count, err := ts.cacheCollection.EstimatedDocumentCount(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error counting cache entries: %w", err)
+	}
+
+	var evicted int64
+	for excess := count - ts.cacheMaxEntries; excess > 0; {
+		limit := excess
+		if limit > evictionChunk {
+			limit = evictionChunk
+		}
+		opts := options.Find().SetSort(evictionSort).SetLimit(limit).SetProjection(bson.M{"_id": 1})
+		cursor, err := ts.cacheCollection.Find(ctx, bson.M{}, opts)
+		if err != nil {
+			return evicted, fmt.Errorf("error finding cache entries to evict: %w", err)
+		}
+		var entries []CacheItem
+		err = cursor.All(ctx, &entries)
+		if err != nil {
+			return evicted, fmt.Errorf("error decoding cache entries to evict: %w", err)
+		}
+		if len(entries) == 0 {
+			break
+		}
+
+		ids := make([]interface{}, len(entries))
+		for i, entry := range entries {
+			ids[i] = entry.ID
+		}
+		result, err := ts.deleteMany(ctx, ts.cacheCollection, bson.M{"_id": bson.M{"$in": ids}})
+		if err != nil {
+			return evicted, fmt.Errorf("error evicting cache entries: %w", err)
+		}
+		evicted += result.DeletedCount
+		excess -= int64(len(entries))
+	}
+
+	log.Printf("🧹 缓存淘汰: 删除 %d 条, 上限 %d", evicted, ts.cacheMaxEntries)
+	return evicted, nil
+}
+
+// runCacheEviction evicts cache entries every interval until ctx is done
+func (ts *TranslationService) runCacheEviction(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := ts.EvictCache(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Error evicting cache entries: %v", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package translation
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// parseSince parses a -since value given as a date or an RFC 3339 timestamp
+func parseSince(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q (expected YYYY-MM-DD or RFC 3339)", value)
+	}
+	return t, nil
+}
+
+// ExportTranslated streams the normalized products that have at least one
+// translated field to path, as CSV when the path ends in .csv and JSON lines
+// otherwise. A non-zero since only exports products updated after it.
+func (ts *TranslationService) ExportTranslated(ctx context.Context, path string, since time.Time) (int, error) {
+	var translatedFilters []bson.M
+	for _, field := range ts.fieldsToTranslate {
+		translatedFilters = append(translatedFilters, bson.M{ts.targetKey(field): bson.M{"$nin": bson.A{nil, ""}}})
+	}
+	filter := bson.M{"$or": translatedFilters}
+	if !since.IsZero() {
+		filter["updatedAt"] = bson.M{"$gte": since}
+	}
+
+	cursor, err := ts.normalizedCollection.Find(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("error finding translated items: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	asCSV := strings.EqualFold(filepath.Ext(path), ".csv")
+
+	var csvWriter *csv.Writer
+	columns := []string{"product_hash"}
+	for _, field := range ts.fieldsToTranslate {
+		columns = append(columns, field, ts.targetKey(field))
+	}
+	if asCSV {
+		csvWriter = csv.NewWriter(writer)
+		err = csvWriter.Write(columns)
+		if err != nil {
+			return 0, fmt.Errorf("failed to write export header: %w", err)
+		}
+	}
+
+	exported := 0
+	for cursor.Next(ctx) {
+		var item NormalizedItem
+		err = cursor.Decode(&item)
+		if err != nil {
+			return exported, fmt.Errorf("error decoding normalized item: %w", err)
+		}
+
+		values := []string{item.ProductHash}
+		for _, field := range ts.fieldsToTranslate {
+			source, translated := ts.itemField(item, field)
+			values = append(values, source, translated)
+		}
+
+		if asCSV {
+			err = csvWriter.Write(values)
+		} else {
+			row := make(map[string]string, len(columns))
+			for i, column := range columns {
+				row[column] = values[i]
+			}
+			var line []byte
+			line, err = json.Marshal(row)
+			if err == nil {
+				_, err = writer.Write(append(line, '\n'))
+			}
+		}
+		if err != nil {
+			return exported, fmt.Errorf("failed to write export row: %w", err)
+		}
+		exported++
+	}
+	if err = cursor.Err(); err != nil {
+		return exported, fmt.Errorf("error iterating normalized items: %w", err)
+	}
+
+	if asCSV {
+		csvWriter.Flush()
+		if err = csvWriter.Error(); err != nil {
+			return exported, fmt.Errorf("failed to write export file: %w", err)
+		}
+	}
+	if err = writer.Flush(); err != nil {
+		return exported, fmt.Errorf("failed to write export file: %w", err)
+	}
+	return exported, nil
+}
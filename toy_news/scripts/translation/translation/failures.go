@@ -0,0 +1,142 @@
+package translation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// setFieldError records why a field of the item got no translation
+func (item *TranslatedItem) setFieldError(field, reason string) {
+	if item.fieldErrors == nil {
+		item.fieldErrors = make(map[string]string)
+	}
+	item.fieldErrors[field] = reason
+}
+
+// setFieldFailure records the error a field of the item got no translation
+// for. Errors that aren't about the item don't count as a failed attempt.
+func (item *TranslatedItem) setFieldFailure(field string, err error) {
+	item.setFieldError(field, err.Error())
+	if !countsAsAttempt(err) {
+		if item.uncounted == nil {
+			item.uncounted = make(map[string]bool)
+		}
+		item.uncounted[field] = true
+	}
+}
+
+// countsAsAttempt reports whether a failure is about the item rather than
+// the run: an exhausted budget, a cancelled cycle or an API that stays
+// unreachable fail every item alike and must not use up its attempts
+func countsAsAttempt(err error) bool {
+	return !errors.Is(err, ErrBudgetExceeded) &&
+		!errors.Is(err, context.Canceled) &&
+		!errors.Is(err, context.DeadlineExceeded) &&
+		!isRetryableAPIError(err)
+}
+
+// failureCounts reports whether any of the fields that got no translation
+// failed for a reason that counts as an attempt
+func (item *TranslatedItem) failureCounts(untranslated []string) bool {
+	for _, field := range untranslated {
+		if !item.uncounted[field] {
+			return true
+		}
+	}
+	return false
+}
+
+// failureReason describes why the item stays pending, given the fields that
+// got no translation
+func (item *TranslatedItem) failureReason(untranslated []string) string {
+	var reasons []string
+	for _, field := range untranslated {
+		reason := item.fieldErrors[field]
+		if reason == "" {
+			reason = "no translation"
+		}
+		reasons = append(reasons, field+": "+reason)
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// maxFailureBackoff caps how long a failing pending item waits between tries
+const maxFailureBackoff = 24 * time.Hour
+
+// recordFailures writes lastError and lastAttemptAt onto the pending items
+// that failed, keyed by product hash. Unless the item is in uncounted, it also
+// counts the attempt and, with a failureBackoff, sets nextAttemptAt:
+// failureBackoff after the first failure, doubling with every further one.
+// With maxAttempts set, items that failed that many times are dead-lettered;
+// the others stay pending.
+func (ts *TranslationService) recordFailures(ctx context.Context, failures map[string]string, uncounted map[string]bool) error {
+	if len(failures) == 0 {
+		return nil
+	}
+
+	hashes := make([]string, 0, len(failures))
+	for hash := range failures {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+
+	now := time.Now()
+	var models []mongo.WriteModel
+	for _, hash := range hashes {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"product_hash": hash}).
+			SetUpdate(ts.failureUpdate(failures[hash], now, !uncounted[hash])))
+	}
+	_, err := ts.bulkWrite(ctx, ts.pendingCollection, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return fmt.Errorf("failed to record pending item errors: %w", err)
+	}
+
+	if ts.maxAttempts > 0 {
+		filter := bson.M{"product_hash": bson.M{"$in": hashes}, "attempts": bson.M{"$gte": ts.maxAttempts}}
+		_, err = ts.deadLetter(ctx, filter, fmt.Sprintf("failed %d attempts", ts.maxAttempts))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// failureUpdate returns the pipeline update recording a failure, counting it
+// as an attempt when counted is set. The backoff is computed from the stored
+// attempt count, so it is right however many instances processed the item
+// before.
+func (ts *TranslationService) failureUpdate(reason string, now time.Time, counted bool) mongo.Pipeline {
+	if !counted {
+		return mongo.Pipeline{{{Key: "$set", Value: bson.M{"lastError": reason, "lastAttemptAt": now}}}}
+	}
+	attempts := bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$attempts", 0}}, 1}}
+	backoff := bson.M{"$min": bson.A{
+		bson.M{"$multiply": bson.A{ts.failureBackoff.Milliseconds(), bson.M{"$pow": bson.A{2, bson.M{"$subtract": bson.A{"$attempts", 1}}}}}},
+		maxFailureBackoff.Milliseconds(),
+	}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"lastError": reason, "lastAttemptAt": now, "attempts": attempts}}},
+	}
+	if ts.failureBackoff > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$set", Value: bson.M{"nextAttemptAt": bson.M{"$add": bson.A{now, backoff}}}}})
+	}
+	return pipeline
+}
+
+// backoffFilter matches the pending items that aren't waiting out the
+// backoff of a failed attempt
+func backoffFilter(now time.Time) bson.M {
+	return bson.M{"$or": bson.A{
+		bson.M{"nextAttemptAt": bson.M{"$exists": false}},
+		bson.M{"nextAttemptAt": bson.M{"$lte": now}},
+	}}
+}
@@ -0,0 +1,149 @@
+package translation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestFailureReason(t *testing.T) {
+	item := &TranslatedItem{}
+	item.setFieldError("name", "refused")
+
+	tests := []struct {
+		untranslated []string
+		want         string
+	}{
+		{[]string{"name"}, "name: refused"},
+		{[]string{"description"}, "description: no translation"},
+		{[]string{"name", "description"}, "name: refused; description: no translation"},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := item.failureReason(tt.untranslated); got != tt.want {
+			t.Errorf("failureReason(%v) = %q, want %q", tt.untranslated, got, tt.want)
+		}
+	}
+}
+
+func TestFailureUpdate(t *testing.T) {
+	ts := &TranslationService{failureBackoff: 5 * time.Minute}
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	pipeline := ts.failureUpdate("name: refused", now, true)
+	if len(pipeline) != 2 {
+		t.Fatalf("failureUpdate() has %d stages, want 2", len(pipeline))
+	}
+
+	// The attempt count must be set before the backoff reads it
+	first := pipeline[0].Map()["$set"].(bson.M)
+	if first["lastError"] != "name: refused" || first["lastAttemptAt"] != now {
+		t.Errorf("first stage = %v", first)
+	}
+	wantAttempts := bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$attempts", 0}}, 1}}
+	if !reflect.DeepEqual(first["attempts"], wantAttempts) {
+		t.Errorf("attempts = %v, want %v", first["attempts"], wantAttempts)
+	}
+	second := pipeline[1].Map()["$set"].(bson.M)
+	wantNext := bson.M{"$add": bson.A{now, bson.M{"$min": bson.A{
+		bson.M{"$multiply": bson.A{int64(300000), bson.M{"$pow": bson.A{2, bson.M{"$subtract": bson.A{"$attempts", 1}}}}}},
+		int64(86400000),
+	}}}}
+	if !reflect.DeepEqual(second["nextAttemptAt"], wantNext) {
+		t.Errorf("nextAttemptAt = %v, want %v", second["nextAttemptAt"], wantNext)
+	}
+}
+
+func TestFailureUpdateUncounted(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name       string
+		ts         *TranslationService
+		counted    bool
+		wantStages int
+	}{
+		{"not counted", &TranslationService{failureBackoff: time.Minute}, false, 1},
+		{"counted without backoff", &TranslationService{}, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipeline := tt.ts.failureUpdate("name: down", now, tt.counted)
+			if len(pipeline) != tt.wantStages {
+				t.Fatalf("failureUpdate() has %d stages, want %d", len(pipeline), tt.wantStages)
+			}
+			set := pipeline[0].Map()["$set"].(bson.M)
+			if _, ok := set["attempts"]; ok != tt.counted {
+				t.Errorf("attempts set = %v, want %v", ok, tt.counted)
+			}
+			if set["lastError"] != "name: down" {
+				t.Errorf("lastError = %v", set["lastError"])
+			}
+		})
+	}
+}
+
+func TestCountsAsAttempt(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"refusal", errors.New("model refused"), true},
+		{"client error", &APIError{StatusCode: 400}, true},
+		{"left out", missingError(&CountMismatchError{Got: 1, Want: 2}), true},
+		{"budget", ErrBudgetExceeded, false},
+		{"budget in a split batch", &CountMismatchError{Got: 1, Want: 2, Err: ErrBudgetExceeded}, false},
+		{"cancelled", fmt.Errorf("batch: %w", context.Canceled), false},
+		{"server errors", &APIError{StatusCode: 503}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countsAsAttempt(tt.err); got != tt.want {
+				t.Errorf("countsAsAttempt(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+
+	item := &TranslatedItem{}
+	item.setFieldFailure("name", ErrBudgetExceeded)
+	if item.failureCounts([]string{"name"}) {
+		t.Error("a budget failure counted as an attempt")
+	}
+	item.setFieldError("description", "model refused")
+	if !item.failureCounts([]string{"name", "description"}) {
+		t.Error("a refused field didn't count as an attempt")
+	}
+}
+
+func TestProcessingFilterBackoff(t *testing.T) {
+	tests := []struct {
+		name        string
+		ts          *TranslationService
+		wantBackoff bool
+	}{
+		{"no backoff", &TranslationService{pendingDisposition: "delete"}, false},
+		{"backoff", &TranslationService{pendingDisposition: "mark", failureBackoff: time.Minute, excludeHashes: []string{"a"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := tt.ts.processingFilter()
+			or, ok := filter["$or"].(bson.A)
+			if ok != tt.wantBackoff {
+				t.Fatalf("processingFilter() = %v, backoff condition %v, want %v", filter, ok, tt.wantBackoff)
+			}
+			if !ok {
+				return
+			}
+			if len(or) != 2 || !reflect.DeepEqual(or[0], bson.M{"nextAttemptAt": bson.M{"$exists": false}}) {
+				t.Errorf("backoff condition = %v", or)
+			}
+			if filter["status"] == nil || filter["product_hash"] == nil {
+				t.Errorf("backoff replaced the other conditions: %v", filter)
+			}
+		})
+	}
+}
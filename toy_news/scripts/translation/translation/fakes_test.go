@@ -0,0 +1,169 @@
+package translation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeAPI is a chat completions server. Unless respond overrides the answer,
+// it answers every numbered text of a request with "译:" and the text.
+type fakeAPI struct {
+	mu      sync.Mutex
+	calls   [][]string // texts of every request, in arrival order
+	auth    []string   // Authorization header of every request
+	models  []string   // served at /models, which is missing when nil
+	respond func(call int, texts []string) (status int, content, finishReason string)
+}
+
+// noChoices is the content respond returns for an answer without choices
+const noChoices = "\x00no choices"
+
+// newFakeAPI starts a fake API and returns a translator talking to it with
+// millisecond retry delays
+func newFakeAPI(t *testing.T, respond func(call int, texts []string) (int, string, string)) (*DeepSeekTranslator, *fakeAPI) {
+	t.Helper()
+	api := &fakeAPI{respond: respond}
+	server := httptest.NewServer(http.HandlerFunc(api.serve))
+	t.Cleanup(server.Close)
+
+	t.Setenv("LOCAL_API_KEY", "")
+	dt := NewLocalTranslator(server.URL, "test-model")
+	dt.plainSingleText = false
+	dt.retryBaseDelay = time.Millisecond
+	dt.retryMaxDelay = time.Millisecond
+	dt.retryJitter = JitterNone
+	return dt, api
+}
+
+// serve answers one chat completion or models request
+func (api *fakeAPI) serve(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/models") {
+		api.serveModels(w)
+		return
+	}
+
+	var req ChatCompletionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	texts := requestTexts(req.Messages[len(req.Messages)-1].Content)
+
+	api.mu.Lock()
+	call := len(api.calls)
+	api.calls = append(api.calls, texts)
+	api.auth = append(api.auth, r.Header.Get("Authorization"))
+	api.mu.Unlock()
+
+	status, content, finishReason := http.StatusOK, numberedAnswer(texts), ""
+	if api.respond != nil {
+		status, content, finishReason = api.respond(call, texts)
+	}
+	if status != http.StatusOK {
+		http.Error(w, "fake failure", status)
+		return
+	}
+	var response ChatCompletionResponse
+	if content != noChoices {
+		response.Choices = []Choice{{Message: Message{Role: "assistant", Content: content}, FinishReason: finishReason}}
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// serveModels lists the models of the fake API
+func (api *fakeAPI) serveModels(w http.ResponseWriter) {
+	if api.models == nil {
+		http.NotFound(w, nil)
+		return
+	}
+	var list modelList
+	for _, model := range api.models {
+		list.Data = append(list.Data, struct {
+			ID string `json:"id"`
+		}{model})
+	}
+	json.NewEncoder(w).Encode(list)
+}
+
+// callCount returns how many requests the fake API received
+func (api *fakeAPI) callCount() int {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return len(api.calls)
+}
+
+// requestTexts extracts the numbered texts from a user prompt, leaving out the
+// instructions that follow the last one
+func requestTexts(prompt string) []string {
+	_, list, _ := strings.Cut(prompt, ":\n")
+	var texts []string
+	for _, item := range strings.Split(list, "\n---\n") {
+		_, text, _ := strings.Cut(item, ". ")
+		text, _, _ = strings.Cut(text, "\n")
+		texts = append(texts, text)
+	}
+	return texts
+}
+
+// numberedAnswer answers texts the way a well-behaved model does
+func numberedAnswer(texts []string) string {
+	var lines []string
+	for i, text := range texts {
+		lines = append(lines, fmt.Sprintf("%d. 译:%s", i+1, text))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// newOfflineTranslator returns a translator whose API calls would fail, for
+// tests that only look at its settings
+func newOfflineTranslator(t *testing.T) *DeepSeekTranslator {
+	t.Helper()
+	t.Setenv("LOCAL_API_KEY", "")
+	return NewLocalTranslator("http://localhost:0", "test-model")
+}
+
+// newTestService returns a service that never connects to MongoDB
+func newTestService(translator Translator, opts ...Option) *TranslationService {
+	return NewTranslationService("", "", "toys", 60, translator, opts...)
+}
+
+// fakeTranslator answers every text from a fixed table, failing with err. It
+// counts its calls into calls when set.
+type fakeTranslator struct {
+	answers map[string]string
+	err     error
+	calls   *int
+}
+
+func (f fakeTranslator) TranslateFieldTexts(ctx context.Context, field string, texts []string) ([]string, error) {
+	if f.calls != nil {
+		*f.calls++
+	}
+	if f.err != nil {
+		return texts, f.err
+	}
+	translations := make([]string, len(texts))
+	for i, text := range texts {
+		translations[i] = f.answers[text]
+	}
+	return translations, nil
+}
+
+func (f fakeTranslator) Provenance(field string) Provenance {
+	return Provenance{Provider: "fake", Model: "fake"}
+}
+
+// trackedTranslator is a fakeTranslator with a spend tracker
+type trackedTranslator struct {
+	fakeTranslator
+	usage *usageTracker
+}
+
+func (t trackedTranslator) UsageTracker() *usageTracker { return t.usage }
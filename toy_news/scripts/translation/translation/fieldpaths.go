@@ -0,0 +1,109 @@
+package translation
+
+import (
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// A field is named by the path its source text is read from. Its translation
+// is written to the field name plus the target suffix ("CN", or the suffix of
+// a pipeline's target language), unless the field was given its own target:
+// a -field pair such as name_raw=name_zh, or a map field storing localized
+// variants of a text in one document, such as
+// localizedName: {"ja": "...", "en": "..."}, where localizedName.ja=zh writes
+// to localizedName.zh.
+
+// parseFieldPairs parses fields given as source=target paths, returning the
+// target by field name
+func parseFieldPairs(specs []string) (map[string]string, error) {
+	targets := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		source, target, ok := strings.Cut(spec, "=")
+		if !ok || source == "" || target == "" {
+			return nil, fmt.Errorf("expected source=target, got %q", spec)
+		}
+		if source == target {
+			return nil, fmt.Errorf("source and target of %q are the same field", spec)
+		}
+		targets[source] = target
+	}
+	return targets, nil
+}
+
+// parseMapFields parses map fields given as map.source=target, returning the
+// target path by field name
+func parseMapFields(specs []string) (map[string]string, error) {
+	targets := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		path, target, ok := strings.Cut(spec, "=")
+		name, source, hasSource := strings.Cut(path, ".")
+		if !ok || !hasSource || name == "" || source == "" || target == "" || strings.Contains(target, ".") {
+			return nil, fmt.Errorf("expected map.source=target, got %q", spec)
+		}
+		if source == target {
+			return nil, fmt.Errorf("source and target of %q are the same key", spec)
+		}
+		targets[path] = name + "." + target
+	}
+	return targets, nil
+}
+
+// targetKey returns the path a field's translation is written to, in dot
+// notation for nested targets
+func (ts *TranslationService) targetKey(field string) string {
+	if target, ok := ts.fieldTargets[field]; ok {
+		return target
+	}
+	return field + ts.targetSuffix
+}
+
+// lookupValue returns the value at a dot-notation path of a document
+func lookupValue(doc bson.M, path string) (interface{}, bool) {
+	key, rest, nested := strings.Cut(path, ".")
+	value, ok := doc[key]
+	if !ok || !nested {
+		return value, ok
+	}
+
+	switch sub := value.(type) {
+	case bson.M:
+		return lookupValue(sub, rest)
+	case map[string]interface{}:
+		return lookupValue(sub, rest)
+	case primitive.D:
+		return lookupValue(sub.Map(), rest)
+	}
+	return nil, false
+}
+
+// lookupPath returns the string at a dot-notation path of a document, ""
+// when there is none
+func lookupPath(doc bson.M, path string) string {
+	value, _ := lookupValue(doc, path)
+	text, _ := value.(string)
+	return text
+}
+
+// value returns the string at a path of a normalized product
+func (item NormalizedItem) value(path string) string {
+	switch path {
+	case "name":
+		return item.Name
+	case "description":
+		return item.Description
+	case "nameCN":
+		return item.NameCN
+	case "descriptionCN":
+		return item.DescriptionCN
+	}
+	return lookupPath(item.Extra, path)
+}
+
+// itemField returns the source text and stored translation of a field of a
+// normalized product
+func (ts *TranslationService) itemField(item NormalizedItem, field string) (string, string) {
+	return item.value(field), item.value(ts.targetKey(field))
+}
@@ -0,0 +1,59 @@
+package translation
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// keyValueFlag collects repeatable key=value flags, e.g. per-field settings
+type keyValueFlag map[string]string
+
+func (f keyValueFlag) String() string {
+	var keys []string
+	for key := range f {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		parts = append(parts, key+"="+f[key])
+	}
+	return strings.Join(parts, ",")
+}
+
+func (f keyValueFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	f[key] = val
+	return nil
+}
+
+// stringsFlag collects a repeatable string flag
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// ints parses the values of the flag as positive integers
+func (f keyValueFlag) ints() (map[string]int, error) {
+	values := make(map[string]int, len(f))
+	for key, val := range f {
+		n, err := strconv.Atoi(val)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("%s: expected a positive integer, got %q", key, val)
+		}
+		values[key] = n
+	}
+	return values, nil
+}
@@ -0,0 +1,80 @@
+package translation
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// parseHashList reads product hashes from a comma-separated list, or from a
+// file with one hash per line when value names a file. Blank lines and lines
+// starting with # are skipped.
+func parseHashList(value string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	separator := ","
+	if info, err := os.Stat(value); err == nil && !info.IsDir() {
+		data, err := os.ReadFile(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read hash file: %w", err)
+		}
+		value = string(data)
+		separator = "\n"
+	}
+
+	var hashes []string
+	for _, hash := range strings.Split(value, separator) {
+		hash = strings.TrimSpace(hash)
+		if hash == "" || strings.HasPrefix(hash, "#") {
+			continue
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, nil
+}
+
+// parseQueryFilter parses a MongoDB query given as extended JSON, such as
+// {"maker": "Bandai"}. An empty value matches everything.
+func parseQueryFilter(value string) (bson.M, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var filter bson.M
+	if err := bson.UnmarshalExtJSON([]byte(value), false, &filter); err != nil {
+		return nil, fmt.Errorf("invalid query %q: %w", value, err)
+	}
+	return filter, nil
+}
+
+// processingFilter returns the pending filter narrowed to the allowed product
+// hashes and the operator's query, leaving out items backing off after a
+// failure. Items outside the allowlist, on the denylist or not matching the
+// query stay pending untouched.
+func (ts *TranslationService) processingFilter() bson.M {
+	filter := ts.pendingFilter()
+	if ts.failureBackoff > 0 {
+		for key, value := range backoffFilter(time.Now()) {
+			filter[key] = value
+		}
+	}
+	hashFilter := bson.M{}
+	if len(ts.includeHashes) > 0 {
+		hashFilter["$in"] = ts.includeHashes
+	}
+	if len(ts.excludeHashes) > 0 {
+		hashFilter["$nin"] = ts.excludeHashes
+	}
+	if len(hashFilter) > 0 {
+		filter["product_hash"] = hashFilter
+	}
+	if len(ts.queryFilter) > 0 {
+		// $and keeps the query from replacing conditions on the same keys
+		return bson.M{"$and": bson.A{filter, ts.queryFilter}}
+	}
+	return filter
+}
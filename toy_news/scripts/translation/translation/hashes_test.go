@@ -0,0 +1,95 @@
+package translation
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestParseHashList(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "hashes.txt")
+	if err := os.WriteFile(file, []byte("# reruns\nabc\n\n  def  \n#ghi\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{"empty", "", nil},
+		{"comma separated", "abc, def,,ghi", []string{"abc", "def", "ghi"}},
+		{"file", file, []string{"abc", "def"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseHashList(tt.value)
+			if err != nil {
+				t.Fatalf("parseHashList: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseHashList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessingFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		ts   *TranslationService
+		want bson.M
+	}{
+		{
+			name: "everything pending",
+			ts:   &TranslationService{pendingDisposition: "delete"},
+			want: bson.M{},
+		},
+		{
+			name: "allowlist and denylist",
+			ts:   &TranslationService{pendingDisposition: "delete", includeHashes: []string{"a", "b"}, excludeHashes: []string{"b"}},
+			want: bson.M{"product_hash": bson.M{"$in": []string{"a", "b"}, "$nin": []string{"b"}}},
+		},
+		{
+			name: "marked items with a denylist",
+			ts:   &TranslationService{pendingDisposition: "mark", excludeHashes: []string{"c"}},
+			want: bson.M{"status": bson.M{"$ne": "done"}, "product_hash": bson.M{"$nin": []string{"c"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.ts.processingFilter(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("processingFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessingFilterWithQuery(t *testing.T) {
+	query, err := parseQueryFilter(`{"maker": "Bandai", "product_hash": {"$ne": "x"}}`)
+	if err != nil {
+		t.Fatalf("parseQueryFilter: %v", err)
+	}
+	ts := &TranslationService{pendingDisposition: "mark", includeHashes: []string{"a"}, queryFilter: query}
+
+	// The query is and-ed, so its product_hash condition doesn't replace the allowlist
+	want := bson.M{"$and": bson.A{
+		bson.M{"status": bson.M{"$ne": "done"}, "product_hash": bson.M{"$in": []string{"a"}}},
+		bson.M{"maker": "Bandai", "product_hash": bson.M{"$ne": "x"}},
+	}}
+	if got := ts.processingFilter(); !reflect.DeepEqual(got, want) {
+		t.Errorf("processingFilter() = %v, want %v", got, want)
+	}
+}
+
+func TestParseQueryFilter(t *testing.T) {
+	if filter, err := parseQueryFilter(""); err != nil || filter != nil {
+		t.Errorf("parseQueryFilter(\"\") = %v, %v, want no filter", filter, err)
+	}
+	if _, err := parseQueryFilter(`{"maker": `); err == nil {
+		t.Error("parseQueryFilter should reject invalid JSON")
+	}
+}
@@ -0,0 +1,19 @@
+package translation
+
+import "strings"
+
+// isIdentity reports whether a translation is just its source text, which is
+// what the source-padding fallbacks leave behind. Such output is never cached
+// or stored, except for trivial texts and fields configured to allow it. The
+// sentences of a text translated sentence by sentence aren't checked on their
+// own, only the text they are put back together into.
+func (ts *TranslationService) isIdentity(field, source, translation string) bool {
+	if ts.identityFields[field] {
+		return false
+	}
+	// Trivial texts are copied on purpose, with or without their boilerplate
+	if stripped, _ := ts.stripBoilerplate(field, source); ts.isTrivial(stripped) {
+		return false
+	}
+	return ts.normalizeOutput(strings.TrimSpace(source)) == ts.normalizeOutput(strings.TrimSpace(translation))
+}
@@ -0,0 +1,32 @@
+package translation
+
+import (
+	"fmt"
+	"os"
+)
+
+// PrintParsedResponse reads a raw model response from path and prints how
+// parseTranslations splits it, expecting items numbered from listStart.
+// Dropped and empty lines are reported by the parser's own warnings.
+func PrintParsedResponse(path string, expectedCount, listStart int) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read response file: %w", err)
+	}
+
+	dt := &DeepSeekTranslator{listStart: listStart}
+	translations, sequential := dt.parseTranslations(string(raw), expectedCount)
+
+	fmt.Printf("Parsed %d translations:\n", len(translations))
+	for i, translation := range translations {
+		fmt.Printf("  [%d] %s\n", i+1, translation)
+	}
+
+	if !sequential {
+		fmt.Println("Warning: items are numbered out of sequence")
+	}
+	if expectedCount > 0 && len(translations) != expectedCount {
+		fmt.Printf("Warning: %v\n", &CountMismatchError{Got: len(translations), Want: expectedCount})
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package translation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencyWindowSize is how many recent API calls the latency percentiles
+// are computed over
+const latencyWindowSize = 1000
+
+// latencyWindow keeps the durations of the most recent API calls in a ring
+type latencyWindow struct {
+	mu        sync.Mutex
+	durations []time.Duration
+	next      int
+}
+
+// latencyTracking is implemented by translators that time their API calls
+type latencyTracking interface {
+	LatencyWindow() *latencyWindow
+}
+
+// add records the duration of one API call
+func (w *latencyWindow) add(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.durations) < latencyWindowSize {
+		w.durations = append(w.durations, d)
+		return
+	}
+	w.durations[w.next] = d
+	w.next = (w.next + 1) % latencyWindowSize
+}
+
+// percentiles returns the nearest-rank percentiles ps (0-100) of the window
+// and the number of calls it holds
+func (w *latencyWindow) percentiles(ps ...float64) ([]time.Duration, int) {
+	w.mu.Lock()
+	sorted := append([]time.Duration(nil), w.durations...)
+	w.mu.Unlock()
+
+	results := make([]time.Duration, len(ps))
+	if len(sorted) == 0 {
+		return results, 0
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	for i, p := range ps {
+		rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+		if rank < 0 {
+			rank = 0
+		}
+		if rank >= len(sorted) {
+			rank = len(sorted) - 1
+		}
+		results[i] = sorted[rank]
+	}
+	return results, len(sorted)
+}
+
+// print prints the p50/p95/p99 latency of the window, if it has calls
+func (w *latencyWindow) print() {
+	if w == nil {
+		return
+	}
+	p, calls := w.percentiles(50, 95, 99)
+	if calls == 0 {
+		return
+	}
+	fmt.Printf("API latency (last %d calls): p50 %s, p95 %s, p99 %s\n",
+		calls, p[0].Round(time.Millisecond), p[1].Round(time.Millisecond), p[2].Round(time.Millisecond))
+}
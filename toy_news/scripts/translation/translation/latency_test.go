@@ -0,0 +1,40 @@
+package translation
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLatencyPercentiles(t *testing.T) {
+	var window latencyWindow
+	// 1ms..100ms, added out of order
+	for i := 100; i >= 1; i-- {
+		window.add(time.Duration(i) * time.Millisecond)
+	}
+	got, calls := window.percentiles(50, 95, 99, 100)
+	want := []time.Duration{50 * time.Millisecond, 95 * time.Millisecond, 99 * time.Millisecond, 100 * time.Millisecond}
+	if calls != 100 || !reflect.DeepEqual(got, want) {
+		t.Errorf("percentiles = %v over %d calls, want %v over 100", got, calls, want)
+	}
+
+	var empty latencyWindow
+	if got, calls := empty.percentiles(50); calls != 0 || got[0] != 0 {
+		t.Errorf("empty window = %v over %d calls", got, calls)
+	}
+}
+
+func TestLatencyWindowSlides(t *testing.T) {
+	var window latencyWindow
+	for i := 0; i < latencyWindowSize; i++ {
+		window.add(time.Second)
+	}
+	// The newest calls push the oldest out
+	for i := 0; i < latencyWindowSize; i++ {
+		window.add(time.Millisecond)
+	}
+	got, calls := window.percentiles(99)
+	if calls != latencyWindowSize || got[0] != time.Millisecond {
+		t.Errorf("p99 = %v over %d calls, want 1ms over %d", got[0], calls, latencyWindowSize)
+	}
+}
@@ -0,0 +1,45 @@
+package translation_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"translation-service/translation"
+)
+
+// upperTranslator is a Translator implemented outside the package
+type upperTranslator struct{ calls int }
+
+func (u *upperTranslator) TranslateFieldTexts(ctx context.Context, field string, texts []string) ([]string, error) {
+	u.calls++
+	translations := make([]string, len(texts))
+	for i, text := range texts {
+		translations[i] = strings.ToUpper(text)
+	}
+	return translations, nil
+}
+
+func (u *upperTranslator) Provenance(field string) translation.Provenance {
+	return translation.Provenance{Provider: "upper", Model: "upper"}
+}
+
+func TestTranslateAsLibrary(t *testing.T) {
+	translator := &upperTranslator{}
+	// Without a cache the service never needs MongoDB
+	service := translation.NewTranslationService("", "", "toys", 60, translator,
+		translation.WithFieldConfig("name", translation.FieldConfig{NoCache: true}))
+
+	got, err := service.Translate(context.Background(), "name", []string{"gundam kit", "", "gundam kit", "zaku ii"})
+	if err != nil {
+		t.Fatalf("Translate: %v", err)
+	}
+	want := []string{"GUNDAM KIT", "", "GUNDAM KIT", "ZAKU II"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Translate = %q, want %q", got, want)
+	}
+	if translator.calls != 1 {
+		t.Errorf("translator called %d times, want one batch", translator.calls)
+	}
+}
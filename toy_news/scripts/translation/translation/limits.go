@@ -0,0 +1,54 @@
+package translation
+
+import (
+	"fmt"
+	"log"
+	"unicode/utf8"
+)
+
+// outputLimits caps how long the translations of a field may get
+type outputLimits struct {
+	maxTokens map[string]int // API output tokens per text, sent as max_tokens
+	maxChars  map[string]int // characters per translation, asked for in the prompt
+}
+
+// requestMaxTokens returns the max_tokens of a request translating count
+// texts of field, or 0 to leave it to the API. Numbered lists get a few
+// tokens per item for the numbering and separators.
+func (l outputLimits) requestMaxTokens(field string, count int) int {
+	perText := l.maxTokens[field]
+	if perText == 0 {
+		return 0
+	}
+	if count == 1 {
+		return perText
+	}
+	return count * (perText + 4)
+}
+
+// instruction returns the length instruction appended to the user message of
+// field, or "" when the field has no character limit
+func (l outputLimits) instruction(field string) string {
+	if maxChars := l.maxChars[field]; maxChars > 0 {
+		return fmt.Sprintf("\n\nKeep each translation within %d characters.", maxChars)
+	}
+	return ""
+}
+
+// flagOverlong logs the translations of field that exceed its character
+// limit and returns how many did. They are kept: the limit is a request to
+// the model, not a reason to drop a translation.
+func (l outputLimits) flagOverlong(field string, translations []string) int {
+	maxChars := l.maxChars[field]
+	if maxChars == 0 {
+		return 0
+	}
+	overlong := 0
+	for _, translation := range translations {
+		if n := utf8.RuneCountInString(translation); n > maxChars {
+			log.Printf("⚠️  %s 译文超过 %d 字符 (%d): %s", field, maxChars, n, translation)
+			overlong++
+		}
+	}
+	return overlong
+}
@@ -0,0 +1,74 @@
+package translation
+
+import "testing"
+
+func TestLRUCacheEviction(t *testing.T) {
+	type op struct {
+		action     string // put, get or remove
+		key, value string
+	}
+	tests := []struct {
+		name     string
+		capacity int
+		ops      []op
+		present  map[string]string
+		absent   []string
+	}{
+		{
+			name:     "evicts the oldest",
+			capacity: 2,
+			ops:      []op{{"put", "a", "1"}, {"put", "b", "2"}, {"put", "c", "3"}},
+			present:  map[string]string{"b": "2", "c": "3"},
+			absent:   []string{"a"},
+		},
+		{
+			name:     "get marks as recently used",
+			capacity: 2,
+			ops:      []op{{"put", "a", "1"}, {"put", "b", "2"}, {"get", "a", ""}, {"put", "c", "3"}},
+			present:  map[string]string{"a": "1", "c": "3"},
+			absent:   []string{"b"},
+		},
+		{
+			name:     "put of a known key updates it",
+			capacity: 2,
+			ops:      []op{{"put", "a", "1"}, {"put", "b", "2"}, {"put", "a", "9"}, {"put", "c", "3"}},
+			present:  map[string]string{"a": "9", "c": "3"},
+			absent:   []string{"b"},
+		},
+		{
+			name:     "remove frees a slot",
+			capacity: 2,
+			ops:      []op{{"put", "a", "1"}, {"put", "b", "2"}, {"remove", "a", ""}, {"put", "c", "3"}},
+			present:  map[string]string{"b": "2", "c": "3"},
+			absent:   []string{"a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newLRUCache(tt.capacity)
+			for _, o := range tt.ops {
+				switch o.action {
+				case "put":
+					c.Put(o.key, o.value)
+				case "get":
+					c.Get(o.key)
+				case "remove":
+					c.Remove(o.key)
+				}
+			}
+			if c.Len() != len(tt.present) {
+				t.Errorf("Len() = %d, want %d", c.Len(), len(tt.present))
+			}
+			for key, want := range tt.present {
+				if got, ok := c.Get(key); !ok || got != want {
+					t.Errorf("Get(%q) = %q, %v, want %q", key, got, ok, want)
+				}
+			}
+			for _, key := range tt.absent {
+				if _, ok := c.Get(key); ok {
+					t.Errorf("Get(%q) found an evicted key", key)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package translation
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+// meterName is the instrumentation scope of the service's metrics
+const meterName = "translation-service"
+
+// otlpExportInterval is how often the metrics are pushed to the collector
+const otlpExportInterval = 30 * time.Second
+
+// serviceMetrics records the cycle and API call instruments exported by
+// -otlp-metrics-endpoint. A nil *serviceMetrics records nothing.
+type serviceMetrics struct {
+	items         metric.Int64Counter // pending items picked up
+	processed     metric.Int64Counter // items that left the queue
+	failures      metric.Int64Counter // items that stayed pending with a failure
+	cacheHits     metric.Int64Counter
+	cacheMisses   metric.Int64Counter
+	apiCalls      metric.Int64Counter
+	cycleErrors   metric.Int64Counter
+	orphans       metric.Int64Counter     // pending items found without a normalized product
+	cycleDuration metric.Float64Histogram // seconds
+	apiDuration   metric.Float64Histogram // seconds, one per HTTP request
+}
+
+// newServiceMetrics creates the instruments on meter
+func newServiceMetrics(meter metric.Meter) (*serviceMetrics, error) {
+	m := &serviceMetrics{}
+	counters := []struct {
+		counter    *metric.Int64Counter
+		name, desc string
+		unit       string
+	}{
+		{&m.items, "translation.items", "Pending items picked up", "{item}"},
+		{&m.processed, "translation.items.processed", "Pending items that left the queue", "{item}"},
+		{&m.failures, "translation.items.failed", "Pending items that stayed pending with a failure", "{item}"},
+		{&m.cacheHits, "translation.cache.hits", "Texts served from the translation cache", "{text}"},
+		{&m.cacheMisses, "translation.cache.misses", "Texts missing from the translation cache", "{text}"},
+		{&m.apiCalls, "translation.api.calls", "Translation API calls, retries not counted", "{call}"},
+		{&m.cycleErrors, "translation.cycle.errors", "Processing cycles that failed", "{cycle}"},
+		{&m.orphans, "translation.items.orphaned", "Pending items found by -find-orphans whose product is gone", "{item}"},
+	}
+	var err error
+	for _, c := range counters {
+		*c.counter, err = meter.Int64Counter(c.name, metric.WithDescription(c.desc), metric.WithUnit(c.unit))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", c.name, err)
+		}
+	}
+	m.cycleDuration, err = meter.Float64Histogram("translation.cycle.duration",
+		metric.WithDescription("Duration of a processing cycle"), metric.WithUnit("s"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create translation.cycle.duration: %w", err)
+	}
+	m.apiDuration, err = meter.Float64Histogram("translation.api.duration",
+		metric.WithDescription("Duration of a translation API request"), metric.WithUnit("s"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create translation.api.duration: %w", err)
+	}
+	return m, nil
+}
+
+// recordCycle records the counts of a cycle's report, attributed to the
+// pipeline when the service runs one and the cache lookups to their field
+func (m *serviceMetrics) recordCycle(ctx context.Context, pipeline string, report *CycleReport) {
+	if m == nil {
+		return
+	}
+	var attrs []attribute.KeyValue
+	if pipeline != "" {
+		attrs = append(attrs, attribute.String("pipeline", pipeline))
+	}
+	opt := metric.WithAttributes(attrs...)
+	m.items.Add(ctx, int64(report.Items), opt)
+	m.processed.Add(ctx, int64(report.Processed), opt)
+	m.failures.Add(ctx, int64(len(report.Failures)), opt)
+	for field, counts := range report.FieldCache {
+		fieldOpt := metric.WithAttributes(append(attrs, attribute.String("field", field))...)
+		m.cacheHits.Add(ctx, counts.Hits, fieldOpt)
+		m.cacheMisses.Add(ctx, counts.Misses, fieldOpt)
+	}
+	m.apiCalls.Add(ctx, report.APICalls, opt)
+	if report.Error != "" {
+		m.cycleErrors.Add(ctx, 1, opt)
+	}
+	m.cycleDuration.Record(ctx, float64(report.DurationMs)/1000, opt)
+}
+
+// recordAPICall records the duration of one API request to provider
+func (m *serviceMetrics) recordAPICall(ctx context.Context, provider, model string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.apiDuration.Record(ctx, duration.Seconds(), metric.WithAttributes(
+		attribute.String("provider", provider),
+		attribute.String("model", model),
+	))
+}
+
+// recordOrphans records the orphaned pending items a -find-orphans run found
+func (m *serviceMetrics) recordOrphans(ctx context.Context, pipeline string, orphans int) {
+	if m == nil {
+		return
+	}
+	var attrs []attribute.KeyValue
+	if pipeline != "" {
+		attrs = append(attrs, attribute.String("pipeline", pipeline))
+	}
+	m.orphans.Add(ctx, int64(orphans), metric.WithAttributes(attrs...))
+}
+
+// newOTLPMeterProvider creates a meter provider pushing the metrics every
+// otlpExportInterval over OTLP/HTTP to the collector at endpoint, such as
+// http://localhost:4318. Shutting it down flushes the last readings.
+func newOTLPMeterProvider(ctx context.Context, endpoint, runID string) (*sdkmetric.MeterProvider, error) {
+	exporter, err := otlpmetrichttp.New(ctx, otlpmetrichttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP metrics exporter: %w", err)
+	}
+	res, err := metricsResource(runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metrics resource: %w", err)
+	}
+	reader := sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(otlpExportInterval))
+	return sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader), sdkmetric.WithResource(res)), nil
+}
+
+// metricsResource describes the process the metrics come from, with the run
+// ID and instance ID of the log prefix so readings can be matched to the logs
+func metricsResource(runID string) (*resource.Resource, error) {
+	attrs := []attribute.KeyValue{semconv.ServiceName(meterName), attribute.String("run.id", runID)}
+	if instance := os.Getenv(instanceIDEnv); instance != "" {
+		attrs = append(attrs, semconv.ServiceInstanceID(instance))
+	}
+	return resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, attrs...))
+}
@@ -0,0 +1,137 @@
+package translation
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestServiceMetricsRecordCycle(t *testing.T) {
+	ctx := context.Background()
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer provider.Shutdown(ctx)
+
+	metrics, err := newServiceMetrics(provider.Meter(meterName))
+	if err != nil {
+		t.Fatalf("newServiceMetrics: %v", err)
+	}
+	metrics.recordCycle(ctx, "jp", &CycleReport{
+		Items:       5,
+		Processed:   3,
+		CacheHits:   7,
+		CacheMisses: 2,
+		FieldCache: map[string]FieldCacheCounts{
+			"name":        {Hits: 5, Misses: 1},
+			"description": {Hits: 2, Misses: 1},
+		},
+		APICalls:   1,
+		DurationMs: 1500,
+		Failures:   map[string]string{"a": "refused", "b": "untranslated"},
+		Error:      "boom",
+	})
+	metrics.recordAPICall(ctx, "deepseek", "deepseek-chat", 250*time.Millisecond)
+	metrics.recordOrphans(ctx, "jp", 4)
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	sums := make(map[string]int64)
+	fieldSums := make(map[string]int64) // metric/field -> sum
+	histograms := make(map[string]metricdata.HistogramDataPoint[float64])
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, point := range data.DataPoints {
+					sums[m.Name] += point.Value
+					if field, ok := point.Attributes.Value("field"); ok {
+						fieldSums[m.Name+"/"+field.AsString()] += point.Value
+					}
+				}
+			case metricdata.Histogram[float64]:
+				histograms[m.Name] = data.DataPoints[0]
+			}
+		}
+	}
+
+	wantSums := map[string]int64{
+		"translation.items":           5,
+		"translation.items.processed": 3,
+		"translation.items.failed":    2,
+		"translation.cache.hits":      7,
+		"translation.cache.misses":    2,
+		"translation.api.calls":       1,
+		"translation.cycle.errors":    1,
+		"translation.items.orphaned":  4,
+	}
+	for name, want := range wantSums {
+		if got, ok := sums[name]; !ok || got != want {
+			t.Errorf("%s = %d (recorded %v), want %d", name, got, ok, want)
+		}
+	}
+
+	wantFieldSums := map[string]int64{
+		"translation.cache.hits/name":          5,
+		"translation.cache.hits/description":   2,
+		"translation.cache.misses/name":        1,
+		"translation.cache.misses/description": 1,
+	}
+	if !reflect.DeepEqual(fieldSums, wantFieldSums) {
+		t.Errorf("cache lookups by field = %v, want %v", fieldSums, wantFieldSums)
+	}
+
+	tests := []struct {
+		name string
+		sum  float64
+		attr string
+	}{
+		{"translation.cycle.duration", 1.5, "pipeline"},
+		{"translation.api.duration", 0.25, "provider"},
+	}
+	for _, tt := range tests {
+		point, ok := histograms[tt.name]
+		if !ok {
+			t.Errorf("%s not recorded", tt.name)
+			continue
+		}
+		if point.Count != 1 || point.Sum != tt.sum {
+			t.Errorf("%s: count %d sum %v, want 1 and %v", tt.name, point.Count, point.Sum, tt.sum)
+		}
+		if _, ok := point.Attributes.Value(attribute.Key(tt.attr)); !ok {
+			t.Errorf("%s: missing attribute %s", tt.name, tt.attr)
+		}
+	}
+}
+
+func TestServiceMetricsNilRecordsNothing(t *testing.T) {
+	var metrics *serviceMetrics
+	metrics.recordCycle(context.Background(), "", &CycleReport{Items: 1})
+	metrics.recordAPICall(context.Background(), "deepseek", "deepseek-chat", time.Second)
+	metrics.recordOrphans(context.Background(), "", 1)
+}
+
+func TestMetricsResourceCarriesRunID(t *testing.T) {
+	t.Setenv(instanceIDEnv, "pod-7")
+	res, err := metricsResource("abc123")
+	if err != nil {
+		t.Fatalf("metricsResource: %v", err)
+	}
+	want := map[attribute.Key]string{
+		"service.name":        meterName,
+		"run.id":              "abc123",
+		"service.instance.id": "pod-7",
+	}
+	set := res.Set()
+	for key, value := range want {
+		if got, ok := set.Value(key); !ok || got.AsString() != value {
+			t.Errorf("%s = %q (set %v), want %q", key, got.AsString(), ok, value)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package translation
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// migrationBatch is how many products one $rename updates at most
+const migrationBatch = 1000
+
+// MigrateFields renames the legacy nameCN and descriptionCN fields of the
+// normalized collection to the targets configured for name and description,
+// in batches. Products that already have the new field keep both, so nothing
+// is overwritten and running it again only picks up what is left. It returns
+// the number of products renamed.
+func (ts *TranslationService) MigrateFields(ctx context.Context) (int64, error) {
+	renames := make(map[string]string)
+	for _, field := range []string{"name", "description"} {
+		if target := ts.targetKey(field); target != field+"CN" {
+			renames[field+"CN"] = target
+		}
+	}
+	if len(renames) == 0 {
+		return 0, fmt.Errorf("name and description still target nameCN and descriptionCN, configure new targets with -field")
+	}
+
+	var total int64
+	for legacy, target := range renames {
+		filter := bson.M{legacy: bson.M{"$exists": true}, target: bson.M{"$exists": false}}
+		for {
+			opts := options.Find().SetProjection(bson.M{"_id": 1}).SetLimit(migrationBatch)
+			cursor, err := ts.normalizedCollection.Find(ctx, filter, opts)
+			if err != nil {
+				return total, fmt.Errorf("error finding products to migrate: %w", err)
+			}
+			var docs []bson.M
+			err = cursor.All(ctx, &docs)
+			if err != nil {
+				return total, fmt.Errorf("error decoding products to migrate: %w", err)
+			}
+			if len(docs) == 0 {
+				break
+			}
+
+			ids := make([]interface{}, len(docs))
+			for i, doc := range docs {
+				ids[i] = doc["_id"]
+			}
+			// The filter is repeated so a product changed meanwhile is left alone
+			batchFilter := bson.M{"_id": bson.M{"$in": ids}, legacy: filter[legacy], target: filter[target]}
+			result, err := ts.updateMany(ctx, ts.normalizedCollection, batchFilter, bson.M{"$rename": bson.M{legacy: target}})
+			if err != nil {
+				return total, fmt.Errorf("error renaming %s: %w", legacy, err)
+			}
+			total += result.ModifiedCount
+			log.Printf("🚚 %s -> %s: %d 个产品已迁移 (累计 %d)", legacy, target, result.ModifiedCount, total)
+			if result.ModifiedCount == 0 {
+				break
+			}
+		}
+
+		conflicts, err := ts.normalizedCollection.CountDocuments(ctx, bson.M{legacy: bson.M{"$exists": true}, target: bson.M{"$exists": true}})
+		if err != nil {
+			return total, fmt.Errorf("error counting migration conflicts: %w", err)
+		}
+		if conflicts > 0 {
+			log.Printf("⚠️  %d 个产品同时有 %s 和 %s，保留未迁移", conflicts, legacy, target)
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,75 @@
+package translation
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// isTransientMongoError reports whether a MongoDB failure is worth another
+// attempt: network trouble, timeouts and errors the server labels
+// retryable. Write errors of single operations aren't.
+func isTransientMongoError(err error) bool {
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) && len(bulkErr.WriteErrors) > 0 {
+		return false
+	}
+	if mongo.IsNetworkError(err) || mongo.IsTimeout(err) {
+		return true
+	}
+	var labeled mongo.LabeledError
+	return errors.As(err, &labeled) && labeled.HasErrorLabel("RetryableWriteError")
+}
+
+// mongoRetryPolicy returns the policy MongoDB connections and writes are
+// retried with, logging each retry of what
+func (ts *TranslationService) mongoRetryPolicy(ctx context.Context, what string) retryPolicy {
+	return retryPolicy{
+		maxRetries: ts.mongoRetries,
+		baseDelay:  time.Second,
+		maxDelay:   10 * time.Second,
+		jitter:     JitterNone,
+		// A cancelled run isn't retried
+		retryable: func(err error) bool { return ctx.Err() == nil && isTransientMongoError(err) },
+		onRetry: func(attempt int, err error, delay time.Duration) {
+			log.Printf("⚠️ MongoDB %s failed (attempt %d/%d): %v, retrying in %s", what, attempt+1, ts.mongoRetries+1, err, delay)
+		},
+	}
+}
+
+// connectMongo connects to MongoDB and pings it, retrying transient failures
+func (ts *TranslationService) connectMongo(ctx context.Context, clientOptions *options.ClientOptions) (*mongo.Client, error) {
+	var client *mongo.Client
+	err := retry(ctx, ts.mongoRetryPolicy(ctx, "connection"), func() error {
+		var err error
+		client, err = mongo.Connect(ctx, clientOptions)
+		if err != nil {
+			return err
+		}
+		if err = client.Ping(ctx, nil); err != nil {
+			client.Disconnect(ctx)
+			return err
+		}
+		return nil
+	})
+	return client, err
+}
+
+// bulkWrite runs a BulkWrite on collection within the write limit, retrying
+// transient failures. A BulkWriteException with write errors isn't retried,
+// its result stands. The write slot is given up while waiting to retry.
+func (ts *TranslationService) bulkWrite(ctx context.Context, collection *mongo.Collection, models []mongo.WriteModel, opts ...*options.BulkWriteOptions) (*mongo.BulkWriteResult, error) {
+	var result *mongo.BulkWriteResult
+	err := retry(ctx, ts.mongoRetryPolicy(ctx, "bulk write to "+collection.Name()), func() error {
+		return ts.limitWrite(ctx, func() error {
+			var err error
+			result, err = collection.BulkWrite(ctx, models, opts...)
+			return err
+		})
+	})
+	return result, err
+}
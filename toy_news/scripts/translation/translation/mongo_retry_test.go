@@ -0,0 +1,32 @@
+package translation
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestIsTransientMongoError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"network", mongo.CommandError{Labels: []string{"NetworkError"}}, true},
+		{"retryable write", mongo.CommandError{Labels: []string{"RetryableWriteError"}}, true},
+		{"timeout", context.DeadlineExceeded, true},
+		{"command failure", mongo.CommandError{Code: 2, Message: "bad value"}, false},
+		{"write errors", mongo.BulkWriteException{
+			WriteErrors: []mongo.BulkWriteError{{WriteError: mongo.WriteError{Index: 0, Code: 11000}}},
+			Labels:      []string{"RetryableWriteError"},
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTransientMongoError(tt.err); got != tt.want {
+				t.Errorf("isTransientMongoError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
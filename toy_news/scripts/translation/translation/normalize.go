@@ -0,0 +1,94 @@
+package translation
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// outputTransform rewrites a translation to follow a target-language
+// convention before it is cached and written
+type outputTransform func(string) string
+
+// outputTransforms are the transforms -normalize-output can name
+var outputTransforms = map[string]outputTransform{
+	"fullwidth-punct": fullwidthPunctuation,
+	"halfwidth":       halfwidthForms,
+	"trim-space":      strings.TrimSpace,
+}
+
+// fullwidthPunct maps ASCII punctuation to its full-width Chinese form
+var fullwidthPunct = map[rune]rune{
+	',': '，',
+	';': '；',
+	':': '：',
+	'?': '？',
+	'!': '！',
+	'(': '（',
+	')': '）',
+}
+
+// fullwidthPunctuation converts ASCII punctuation next to a Chinese character
+// to full width. Punctuation between Latin text or digits, as in "1,000" or
+// "Ver.2 (A)", is left alone.
+func fullwidthPunctuation(text string) string {
+	runes := []rune(text)
+	for i, r := range runes {
+		full, ok := fullwidthPunct[r]
+		if !ok {
+			continue
+		}
+		prevHan := i > 0 && unicode.Is(unicode.Han, runes[i-1])
+		nextHan := i+1 < len(runes) && unicode.Is(unicode.Han, runes[i+1])
+		if prevHan || nextHan {
+			runes[i] = full
+		}
+	}
+	return string(runes)
+}
+
+// halfwidthForms converts full-width ASCII variants (letters, digits and
+// punctuation) and the ideographic space to their half-width forms
+func halfwidthForms(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '　':
+			return ' '
+		case r >= '！' && r <= '～':
+			return r - 0xFEE0
+		}
+		return r
+	}, text)
+}
+
+// normalizeSourceKey returns the form of a source text that near-duplicates
+// share: half-width ASCII, with whitespace runs collapsed and trimmed
+func normalizeSourceKey(text string) string {
+	return strings.Join(strings.Fields(halfwidthForms(text)), " ")
+}
+
+// parseOutputTransforms resolves a comma-separated list of transform names,
+// applied in the given order
+func parseOutputTransforms(spec string) ([]outputTransform, error) {
+	var transforms []outputTransform
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		transform, ok := outputTransforms[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown output normalization %q (expected fullwidth-punct, halfwidth or trim-space)", name)
+		}
+		transforms = append(transforms, transform)
+	}
+	return transforms, nil
+}
+
+// normalizeOutput applies the configured output transforms to a translation
+func (ts *TranslationService) normalizeOutput(translation string) string {
+	for _, transform := range ts.outputTransforms {
+		translation = transform(translation)
+	}
+	return translation
+}
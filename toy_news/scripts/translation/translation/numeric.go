@@ -0,0 +1,35 @@
+package translation
+
+import (
+	"math"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// toInt64 converts a numeric value decoded from an aggregation result to an
+// int64. $sum and friends return int32, int64, double or decimal depending on
+// the inputs, so every BSON number type is accepted. Fractional values are
+// rounded to the nearest integer.
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return 0, false
+		}
+		return int64(math.Round(v)), true
+	case primitive.Decimal128:
+		f, err := strconv.ParseFloat(v.String(), 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return 0, false
+		}
+		return int64(math.Round(f)), true
+	}
+	return 0, false
+}
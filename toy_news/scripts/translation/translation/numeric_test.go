@@ -0,0 +1,43 @@
+package translation
+
+import (
+	"math"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestToInt64(t *testing.T) {
+	decimal := func(s string) primitive.Decimal128 {
+		d, err := primitive.ParseDecimal128(s)
+		if err != nil {
+			t.Fatalf("ParseDecimal128(%q): %v", s, err)
+		}
+		return d
+	}
+	tests := []struct {
+		name   string
+		value  interface{}
+		want   int64
+		wantOK bool
+	}{
+		{"int32", int32(42), 42, true},
+		{"int64", int64(1) << 40, 1 << 40, true},
+		{"int", 7, 7, true},
+		{"float64", 2.6, 3, true},
+		{"float64 NaN", math.NaN(), 0, false},
+		{"float64 Inf", math.Inf(1), 0, false},
+		{"decimal128", decimal("12345.4"), 12345, true},
+		{"decimal128 NaN", decimal("NaN"), 0, false},
+		{"string", "12", 0, false},
+		{"nil", nil, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := toInt64(tt.value)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("toInt64(%v) = %d, %v, want %d, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
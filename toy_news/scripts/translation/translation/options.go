@@ -0,0 +1,159 @@
+package translation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Option customizes a TranslationService created by NewTranslationService
+type Option func(*TranslationService)
+
+// CacheKeyFunc returns the cache key of a source text
+type CacheKeyFunc func(text string) string
+
+// BatchAssembler groups the texts of a field sent to the translator, as
+// lists of indices into texts. Every group is translated with its own
+// TranslateFieldTexts call; texts in no group stay untranslated.
+type BatchAssembler func(field string, texts []string) [][]int
+
+// FieldConfig configures one translated field
+type FieldConfig struct {
+	Target       string // path the translation is written to, default field plus the target suffix
+	NoCache      bool   // always translate by the API and never cache
+	SubBatchSize int    // texts per API call with a DeepSeekTranslator, 0 keeps its setting
+	Concurrency  int    // parallel API calls with a DeepSeekTranslator, 0 keeps its setting
+}
+
+// WithTranslator sets the translator, taking the place of the one passed to
+// NewTranslationService
+func WithTranslator(translator Translator) Option {
+	return func(ts *TranslationService) {
+		ts.translator = translator
+	}
+}
+
+// WithCacheKeyFunc replaces the MD5 hash cache entries are keyed by. Existing
+// caches need -rehash-cache after a change.
+func WithCacheKeyFunc(key CacheKeyFunc) Option {
+	return func(ts *TranslationService) {
+		ts.cacheKey = key
+	}
+}
+
+// WithBatchAssembler sets how the texts of a field are grouped into
+// translator calls, instead of sending all of them at once
+func WithBatchAssembler(assemble BatchAssembler) Option {
+	return func(ts *TranslationService) {
+		ts.assembler = assemble
+	}
+}
+
+// WithFieldConfig translates field with cfg, adding it to the translated
+// fields. Sub-batch and concurrency settings apply once all options are set,
+// whichever translator they set.
+func WithFieldConfig(field string, cfg FieldConfig) Option {
+	return func(ts *TranslationService) {
+		known := false
+		for _, f := range ts.fieldsToTranslate {
+			known = known || f == field
+		}
+		if !known {
+			ts.fieldsToTranslate = append(ts.fieldsToTranslate, field)
+		}
+		if cfg.Target != "" {
+			if ts.fieldTargets == nil {
+				ts.fieldTargets = make(map[string]string)
+			}
+			ts.fieldTargets[field] = cfg.Target
+		}
+		if cfg.NoCache {
+			if ts.noCacheFields == nil {
+				ts.noCacheFields = make(map[string]bool)
+			}
+			ts.noCacheFields[field] = true
+		}
+		if ts.fieldConfigs == nil {
+			ts.fieldConfigs = make(map[string]FieldConfig)
+		}
+		ts.fieldConfigs[field] = cfg
+	}
+}
+
+// applyFieldConfigs hands the per-field API call settings to the translator
+func (ts *TranslationService) applyFieldConfigs() {
+	dt, ok := ts.translator.(*DeepSeekTranslator)
+	if !ok {
+		return
+	}
+	for field, cfg := range ts.fieldConfigs {
+		if cfg.SubBatchSize > 0 {
+			if dt.fieldSubBatch == nil {
+				dt.fieldSubBatch = make(map[string]int)
+			}
+			dt.fieldSubBatch[field] = cfg.SubBatchSize
+		}
+		if cfg.Concurrency > 0 {
+			if dt.fieldConcurrency == nil {
+				dt.fieldConcurrency = make(map[string]int)
+			}
+			dt.fieldConcurrency[field] = cfg.Concurrency
+		}
+	}
+}
+
+// translateTexts translates texts of a field with the translator, in the
+// groups of the batch assembler when one is set. The results follow the
+// translator's contract: texts of failed or missing groups are reported in a
+// *CountMismatchError, unless nothing at all was translated.
+func (ts *TranslationService) translateTexts(ctx context.Context, field string, texts []string) ([]string, error) {
+	if ts.assembler == nil {
+		return ts.translator.TranslateFieldTexts(ctx, field, texts)
+	}
+
+	results := append([]string(nil), texts...)
+	translated := make(map[int]bool)
+	mismatch := &CountMismatchError{Want: len(texts)}
+	for _, group := range ts.assembler(field, texts) {
+		sent := make([]string, 0, len(group))
+		for _, index := range group {
+			if index < 0 || index >= len(texts) {
+				return texts, fmt.Errorf("batch assembler returned index %d for %d texts", index, len(texts))
+			}
+			sent = append(sent, texts[index])
+		}
+		if len(sent) == 0 {
+			continue
+		}
+
+		translations, err := ts.translator.TranslateFieldTexts(ctx, field, sent)
+		var m *CountMismatchError
+		if err != nil && !errors.As(err, &m) {
+			if mismatch.Err == nil {
+				mismatch.Err = err
+			}
+			continue
+		}
+		missing := untranslatedIndices(err)
+		for i, index := range group {
+			if i < len(translations) && !missing[i] {
+				results[index] = translations[i]
+				translated[index] = true
+			}
+		}
+	}
+
+	mismatch.Got = len(translated)
+	if mismatch.Got == len(texts) {
+		return results, nil
+	}
+	if mismatch.Got == 0 && mismatch.Err != nil {
+		return texts, mismatch.Err
+	}
+	for index := range texts {
+		if !translated[index] {
+			mismatch.Missing = append(mismatch.Missing, index)
+		}
+	}
+	return results, mismatch
+}
@@ -0,0 +1,103 @@
+package translation
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWithFieldConfig(t *testing.T) {
+	dt := newOfflineTranslator(t)
+	ts := newTestService(dt,
+		WithFieldConfig("name", FieldConfig{SubBatchSize: 4}),
+		WithFieldConfig("maker", FieldConfig{Target: "makerZH", NoCache: true, Concurrency: 2}),
+	)
+
+	if want := []string{"name", "description", "maker"}; !reflect.DeepEqual(ts.fieldsToTranslate, want) {
+		t.Errorf("fields = %v, want %v", ts.fieldsToTranslate, want)
+	}
+	if want := map[string]string{"maker": "makerZH"}; !reflect.DeepEqual(ts.fieldTargets, want) {
+		t.Errorf("targets = %v, want %v", ts.fieldTargets, want)
+	}
+	if want := map[string]bool{"maker": true}; !reflect.DeepEqual(ts.noCacheFields, want) {
+		t.Errorf("uncached fields = %v, want %v", ts.noCacheFields, want)
+	}
+	if want := map[string]int{"name": 4}; !reflect.DeepEqual(dt.fieldSubBatch, want) {
+		t.Errorf("sub-batch sizes = %v, want %v", dt.fieldSubBatch, want)
+	}
+	if want := map[string]int{"maker": 2}; !reflect.DeepEqual(dt.fieldConcurrency, want) {
+		t.Errorf("concurrency = %v, want %v", dt.fieldConcurrency, want)
+	}
+}
+
+func TestFieldConfigAppliesToReplacedTranslator(t *testing.T) {
+	replacement := newOfflineTranslator(t)
+	newTestService(newOfflineTranslator(t),
+		WithFieldConfig("name", FieldConfig{SubBatchSize: 3}),
+		WithTranslator(replacement),
+	)
+	if replacement.fieldSubBatch["name"] != 3 {
+		t.Errorf("sub-batch sizes = %v, want them on the replacement translator", replacement.fieldSubBatch)
+	}
+}
+
+func TestWithCacheKeyFunc(t *testing.T) {
+	ts := newTestService(fakeTranslator{})
+	if got := ts.GetTextHash("abc"); got != "900150983cd24fb0d6963f7d28e17f72" {
+		t.Errorf("GetTextHash() = %q, want the MD5 hash", got)
+	}
+	ts = newTestService(fakeTranslator{},
+		WithCacheKeyFunc(func(text string) string { return "key:" + text }))
+	if got := ts.GetTextHash("abc"); got != "key:abc" {
+		t.Errorf("GetTextHash() = %q, want key:abc", got)
+	}
+}
+
+func TestWithBatchAssembler(t *testing.T) {
+	answers := map[string]string{"a": "A", "b": "B", "c": "C"}
+	texts := []string{"a", "b", "c"}
+
+	tests := []struct {
+		name      string
+		groups    [][]int
+		want      []string
+		wantErr   bool
+		wantCalls int
+	}{
+		{"one group per text", [][]int{{0}, {1}, {2}}, []string{"A", "B", "C"}, false, 3},
+		{"reordered groups", [][]int{{2, 0}, {1}}, []string{"A", "B", "C"}, false, 2},
+		{"left out text", [][]int{{0, 1}, {}}, []string{"A", "B", "c"}, true, 1},
+		{"index out of range", [][]int{{3}}, texts, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			translator := fakeTranslator{answers: answers, calls: &calls}
+			ts := newTestService(translator,
+				WithBatchAssembler(func(field string, texts []string) [][]int { return tt.groups }))
+
+			got, err := ts.translateTexts(context.Background(), "name", texts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("translateTexts() = %v, want %v", got, tt.want)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("translator called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestBatchAssemblerReportsUntranslated(t *testing.T) {
+	ts := newTestService(fakeTranslator{answers: map[string]string{"a": "A"}},
+		WithBatchAssembler(func(field string, texts []string) [][]int { return [][]int{{0}} }))
+
+	_, err := ts.translateTexts(context.Background(), "name", []string{"a", "b"})
+	var mismatch *CountMismatchError
+	if !errors.As(err, &mismatch) || mismatch.Got != 1 || mismatch.Want != 2 {
+		t.Errorf("err = %v, want a count mismatch of 1 of 2", err)
+	}
+}
@@ -0,0 +1,111 @@
+package translation
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// orphanCheckChunk is how many pending product hashes are looked up in the
+// normalized collection at a time
+const orphanCheckChunk = 500
+
+// FindOrphans returns the product hashes of pending items whose product is not
+// in the normalized collection, so their translations could never be stored.
+// With deadLetter they are moved to the dead-letter collection as well. Items
+// without a product hash are left to the processing cycle, items that can't
+// be decoded are logged and counted but not checked, and items marked done
+// are skipped since nothing is left to store for them. The count is recorded
+// in the metrics.
+func (ts *TranslationService) FindOrphans(ctx context.Context, deadLetter bool) ([]string, error) {
+	filter := orphanFilter()
+	opts := options.Find().SetProjection(bson.M{"product_hash": 1}).SetBatchSize(orphanCheckChunk)
+	cursor, err := ts.pendingCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding pending items: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var orphans []string
+	var chunk []UpdateOperation
+	undecodable := 0
+	check := func() error {
+		missing, err := ts.missingProducts(ctx, chunk)
+		if err != nil {
+			return fmt.Errorf("error checking normalized products: %w", err)
+		}
+		for _, op := range chunk {
+			if missing[op.ProductHash] {
+				orphans = append(orphans, op.ProductHash)
+			}
+		}
+		chunk = chunk[:0]
+		return nil
+	}
+	for cursor.Next(ctx) {
+		var item PendingItem
+		if err := cursor.Decode(&item); err != nil {
+			undecodable++
+			log.Printf("⚠️ 无法解析待翻译项目 %v: %v", cursor.Current.Lookup("_id"), err)
+			continue
+		}
+		chunk = append(chunk, UpdateOperation{ProductHash: item.ProductHash})
+		if len(chunk) >= orphanCheckChunk {
+			if err := check(); err != nil {
+				return orphans, err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return orphans, fmt.Errorf("error iterating pending items: %w", err)
+	}
+	if undecodable > 0 {
+		log.Printf("⚠️ %d 个待翻译项目无法解析，未检查是否孤立", undecodable)
+	}
+	if len(chunk) > 0 {
+		if err := check(); err != nil {
+			return orphans, err
+		}
+	}
+
+	ts.metrics.recordOrphans(ctx, ts.pipeline, len(orphans))
+
+	if deadLetter {
+		for start := 0; start < len(orphans); start += orphanCheckChunk {
+			end := start + orphanCheckChunk
+			if end > len(orphans) {
+				end = len(orphans)
+			}
+			filter := orphanFilter()
+			filter["product_hash"] = bson.M{"$in": orphans[start:end]}
+			_, err := ts.deadLetter(ctx, filter, "normalized product not found")
+			if err != nil {
+				return orphans, err
+			}
+		}
+	}
+	return orphans, nil
+}
+
+// orphanFilter matches the pending items FindOrphans checks: those with a
+// product hash that weren't marked done
+func orphanFilter() bson.M {
+	return bson.M{
+		"product_hash": bson.M{"$nin": bson.A{"", nil}},
+		"status":       bson.M{"$ne": "done"},
+	}
+}
+
+// PrintOrphans prints the orphaned pending items found by FindOrphans
+func (ts *TranslationService) PrintOrphans(orphans []string, deadLettered bool) {
+	fmt.Printf("🔍 %d 个待翻译项目的产品在 %s 中不存在\n", len(orphans), ts.mongoCollection)
+	for _, hash := range orphans {
+		fmt.Printf("  %s\n", hash)
+	}
+	if deadLettered && len(orphans) > 0 {
+		fmt.Printf("  已移入 %s\n", deadLetterCollectionName)
+	}
+}
@@ -0,0 +1,18 @@
+package translation
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestOrphanFilterSkipsDoneItems(t *testing.T) {
+	got := orphanFilter()
+	if !reflect.DeepEqual(got["status"], bson.M{"$ne": "done"}) {
+		t.Errorf("status filter = %v, want items marked done skipped", got["status"])
+	}
+	if _, ok := got["product_hash"]; !ok {
+		t.Error("filter should only match items with a product hash")
+	}
+}
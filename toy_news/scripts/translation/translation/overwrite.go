@@ -0,0 +1,95 @@
+package translation
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Overwrite modes deciding whether a translation replaces an existing target
+// value in the normalized collection
+const (
+	OverwriteAlways  = "always"
+	OverwriteIfEmpty = "if-empty"
+	OverwriteNever   = "never"
+)
+
+// parseOverwrite validates an overwrite mode
+func parseOverwrite(mode string) (string, error) {
+	switch mode {
+	case OverwriteAlways, OverwriteIfEmpty, OverwriteNever:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown overwrite mode %q (expected always, if-empty or never)", mode)
+}
+
+// Timestamp sources of the updatedAt written with a translation
+const (
+	TimestampServer = "server"
+	TimestampClient = "client"
+)
+
+// parseTimestampSource validates a timestamp source
+func parseTimestampSource(source string) (string, error) {
+	switch source {
+	case TimestampServer, TimestampClient:
+		return source, nil
+	}
+	return "", fmt.Errorf("unknown timestamp source %q (expected server or client)", source)
+}
+
+// normalizedUpdate returns the update writing the values of an operation.
+// With OverwriteAlways the values are simply set. The other modes use an
+// update pipeline that keeps an existing target value: never keeps any value
+// that is present, if-empty only replaces null and empty strings. updatedAt
+// is the server time, or the time of this call with TimestampClient.
+func (ts *TranslationService) normalizedUpdate(values bson.M) interface{} {
+	var clientTime interface{}
+	if ts.timestampSource == TimestampClient {
+		clientTime = time.Now()
+	}
+
+	if ts.overwrite == "" || ts.overwrite == OverwriteAlways {
+		if clientTime != nil {
+			set := bson.M{"updatedAt": clientTime}
+			for key, value := range values {
+				set[key] = value
+			}
+			return bson.M{"$set": set}
+		}
+		return bson.M{
+			"$set":         values,
+			"$currentDate": bson.M{"updatedAt": true},
+		}
+	}
+
+	targets := make(map[string]bool, len(ts.fieldsToTranslate))
+	for _, field := range ts.fieldsToTranslate {
+		targets[ts.targetKey(field)] = true
+	}
+
+	set := bson.M{"updatedAt": "$$NOW"}
+	if clientTime != nil {
+		set["updatedAt"] = bson.M{"$literal": clientTime}
+	}
+	for key, value := range values {
+		// $literal keeps a translation starting with $ from reading as a path
+		literal := bson.M{"$literal": value}
+		if !targets[key] {
+			set[key] = literal
+			continue
+		}
+
+		current := "$" + key
+		var keep bson.M
+		if ts.overwrite == OverwriteNever {
+			keep = bson.M{"$ne": bson.A{bson.M{"$ifNull": bson.A{current, nil}}, nil}}
+		} else {
+			keep = bson.M{"$not": bson.A{bson.M{"$in": bson.A{bson.M{"$ifNull": bson.A{current, ""}}, bson.A{""}}}}}
+		}
+		set[key] = bson.M{"$cond": bson.A{keep, current, literal}}
+	}
+	return mongo.Pipeline{{{Key: "$set", Value: set}}}
+}
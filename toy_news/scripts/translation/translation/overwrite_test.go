@@ -0,0 +1,147 @@
+package translation
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// applyUpdate applies an update returned by normalizedUpdate to doc,
+// evaluating the few aggregation operators the overwrite pipelines use
+func applyUpdate(t *testing.T, doc bson.M, update interface{}) bson.M {
+	t.Helper()
+	result := bson.M{}
+	for key, value := range doc {
+		result[key] = value
+	}
+	switch u := update.(type) {
+	case bson.M:
+		for key, value := range u["$set"].(bson.M) {
+			result[key] = value
+		}
+	case mongo.Pipeline:
+		for key, expr := range u[0][0].Value.(bson.M) {
+			if key != "updatedAt" {
+				result[key] = evalExpr(t, doc, expr)
+			}
+		}
+	default:
+		t.Fatalf("unexpected update %T", update)
+	}
+	return result
+}
+
+// evalExpr evaluates an aggregation expression against doc
+func evalExpr(t *testing.T, doc bson.M, expr interface{}) interface{} {
+	t.Helper()
+	switch e := expr.(type) {
+	case string:
+		if strings.HasPrefix(e, "$") {
+			return doc[e[1:]]
+		}
+		return e
+	case bson.A:
+		values := make(bson.A, len(e))
+		for i, item := range e {
+			values[i] = evalExpr(t, doc, item)
+		}
+		return values
+	case bson.M:
+		for op, arg := range e {
+			if op == "$literal" {
+				return arg
+			}
+			args := evalExpr(t, doc, arg).(bson.A)
+			switch op {
+			case "$cond":
+				if args[0].(bool) {
+					return args[1]
+				}
+				return args[2]
+			case "$ne":
+				return args[0] != args[1]
+			case "$not":
+				return !args[0].(bool)
+			case "$ifNull":
+				if args[0] == nil {
+					return args[1]
+				}
+				return args[0]
+			case "$in":
+				for _, item := range args[1].(bson.A) {
+					if item == args[0] {
+						return true
+					}
+				}
+				return false
+			}
+			t.Fatalf("unsupported operator %s", op)
+		}
+	}
+	return expr
+}
+
+func TestNormalizedUpdateOverwrite(t *testing.T) {
+	docs := []struct {
+		name string
+		doc  bson.M
+	}{
+		{"existing", bson.M{"nameCN": "旧译名"}},
+		{"empty", bson.M{"nameCN": ""}},
+		{"missing", bson.M{}},
+	}
+	want := map[string][]interface{}{ // mode -> nameCN afterwards, by doc
+		OverwriteAlways:  {"新译名", "新译名", "新译名"},
+		OverwriteIfEmpty: {"旧译名", "新译名", "新译名"},
+		OverwriteNever:   {"旧译名", "", "新译名"},
+	}
+	for mode, wantNames := range want {
+		for i, d := range docs {
+			t.Run(mode+"/"+d.name, func(t *testing.T) {
+				ts := newTestService(fakeTranslator{})
+				ts.overwrite = mode
+				got := applyUpdate(t, d.doc, ts.normalizedUpdate(bson.M{"nameCN": "新译名", "translation_status": "complete"}))
+				if got["nameCN"] != wantNames[i] {
+					t.Errorf("nameCN = %v, want %v", got["nameCN"], wantNames[i])
+				}
+				if got["translation_status"] != "complete" {
+					t.Errorf("other values should always be set, got %v", got)
+				}
+			})
+		}
+	}
+	if _, err := parseOverwrite("sometimes"); err == nil {
+		t.Error("parseOverwrite should reject an unknown mode")
+	}
+}
+
+func TestNormalizedUpdateTimestamp(t *testing.T) {
+	values := bson.M{"nameCN": "高达"}
+
+	ts := newTestService(fakeTranslator{})
+	server := ts.normalizedUpdate(values).(bson.M)
+	if !reflect.DeepEqual(server["$currentDate"], bson.M{"updatedAt": true}) {
+		t.Errorf("server mode update = %v, want $currentDate on updatedAt", server)
+	}
+
+	ts.timestampSource = TimestampClient
+	before := time.Now()
+	client := ts.normalizedUpdate(values).(bson.M)
+	if _, ok := client["$currentDate"]; ok {
+		t.Errorf("client mode update = %v, want no $currentDate", client)
+	}
+	stamp, ok := client["$set"].(bson.M)["updatedAt"].(time.Time)
+	if !ok || stamp.Before(before) || time.Since(stamp) > time.Minute {
+		t.Errorf("client mode updatedAt = %v, want the time of the call", client["$set"])
+	}
+
+	ts.overwrite = OverwriteNever
+	pipeline := ts.normalizedUpdate(values).(mongo.Pipeline)
+	if literal, ok := pipeline[0][0].Value.(bson.M)["updatedAt"].(bson.M); !ok || literal["$literal"] == nil {
+		t.Errorf("client mode pipeline updatedAt = %v, want the call time as a literal", pipeline[0][0].Value)
+	}
+}
@@ -0,0 +1,185 @@
+package translation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+)
+
+// PipelineConfig represents one named pipeline of a -pipelines file: a
+// pending queue translated into a collection with its own provider, target
+// language and fields. Empty settings fall back to the command line.
+type PipelineConfig struct {
+	Name              string   `json:"name"`
+	PendingCollection string   `json:"pending_collection"`
+	Collection        string   `json:"collection"`       // normalized collection the translations are written to
+	CacheCollection   string   `json:"cache_collection"` // pipelines with different target languages need their own
+	TargetLanguage    string   `json:"target_language"`
+	TargetSuffix      string   `json:"target_suffix"` // appended to a field for its translation, by default from target_language
+	Provider          string   `json:"provider"`
+	APIBase           string   `json:"api_base"`
+	Model             string   `json:"model"`
+	Fields            []string `json:"fields"`
+	BatchSize         int      `json:"batch_size"`
+	CheckInterval     int      `json:"check_interval"` // seconds between cycles
+}
+
+// targetSuffixes are the default target suffixes of the target languages.
+// Other languages need an explicit target_suffix.
+var targetSuffixes = map[string]string{
+	"Chinese":  "CN",
+	"English":  "EN",
+	"Japanese": "JA",
+	"Korean":   "KO",
+	"French":   "FR",
+	"German":   "DE",
+	"Spanish":  "ES",
+}
+
+// loadPipelines reads the JSON array of pipelines at path. Names and pending
+// collections must be unique, a cache collection can't be shared by pipelines
+// translating into different languages and neither can the target suffix in
+// one collection. A pipeline's provider gets its default API base and model.
+func loadPipelines(path string) ([]PipelineConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pipelines file: %w", err)
+	}
+	var pipelines []PipelineConfig
+	if err := json.Unmarshal(data, &pipelines); err != nil {
+		return nil, fmt.Errorf("invalid pipelines file: %w", err)
+	}
+	if len(pipelines) == 0 {
+		return nil, errors.New("pipelines file lists no pipelines")
+	}
+
+	names := make(map[string]bool)
+	queues := make(map[string]string)
+	cacheTargets := make(map[string]string)
+	suffixTargets := make(map[string]string)
+	for i := range pipelines {
+		p := &pipelines[i]
+		if p.Name == "" || p.PendingCollection == "" {
+			return nil, fmt.Errorf("pipeline %d: name and pending_collection are required", i+1)
+		}
+		if names[p.Name] {
+			return nil, fmt.Errorf("pipeline %s: duplicate name", p.Name)
+		}
+		names[p.Name] = true
+		if other, ok := queues[p.PendingCollection]; ok {
+			return nil, fmt.Errorf("pipelines %s and %s share the pending collection %s", other, p.Name, p.PendingCollection)
+		}
+		queues[p.PendingCollection] = p.Name
+
+		if p.CacheCollection == "" {
+			p.CacheCollection = defaultCacheCollection
+		}
+		if p.TargetLanguage == "" {
+			p.TargetLanguage = defaultTargetLanguage
+		}
+		if target, ok := cacheTargets[p.CacheCollection]; ok && target != p.TargetLanguage {
+			return nil, fmt.Errorf("pipeline %s: cache collection %s already holds %s translations", p.Name, p.CacheCollection, target)
+		}
+		cacheTargets[p.CacheCollection] = p.TargetLanguage
+
+		if p.TargetSuffix == "" {
+			p.TargetSuffix = targetSuffixes[p.TargetLanguage]
+			if p.TargetSuffix == "" {
+				return nil, fmt.Errorf("pipeline %s: target_suffix is required for %s translations", p.Name, p.TargetLanguage)
+			}
+		}
+		key := p.Collection + "\x00" + p.TargetSuffix
+		if target, ok := suffixTargets[key]; ok && target != p.TargetLanguage {
+			return nil, fmt.Errorf("pipeline %s: %s translations already use the target suffix %s", p.Name, target, p.TargetSuffix)
+		}
+		suffixTargets[key] = p.TargetLanguage
+
+		if p.Provider != "" {
+			p.APIBase, p.Model, err = providerDefaults(p.Provider, p.APIBase, p.Model)
+			if err != nil {
+				return nil, fmt.Errorf("pipeline %s: %w", p.Name, err)
+			}
+		}
+	}
+	return pipelines, nil
+}
+
+// newPipelineService creates the service of a pipeline. Settings the pipeline
+// leaves empty are taken from defaults, which carries the command line's.
+// configureTranslator and opts apply the shared settings before the
+// pipeline's own target language, collections, fields and batch size. The
+// fields configured by opts are translated along with the pipeline's.
+func newPipelineService(p PipelineConfig, defaults PipelineConfig, mongoURI, mongoDB string, configureTranslator func(Translator), opts ...Option) (*TranslationService, error) {
+	if p.Collection == "" {
+		p.Collection = defaults.Collection
+	}
+	if p.Provider == "" {
+		p.Provider, p.APIBase, p.Model = defaults.Provider, defaults.APIBase, defaults.Model
+	}
+	if p.CheckInterval == 0 {
+		p.CheckInterval = defaults.CheckInterval
+	}
+	if p.BatchSize == 0 {
+		p.BatchSize = defaults.BatchSize
+	}
+
+	translator, err := newTranslator(p.Provider, p.APIBase, p.Model)
+	if err != nil {
+		return nil, fmt.Errorf("pipeline %s: %w", p.Name, err)
+	}
+	if configureTranslator != nil {
+		configureTranslator(translator)
+	}
+	if dt, ok := translator.(*DeepSeekTranslator); ok {
+		dt.prompts.target = p.TargetLanguage
+	}
+
+	service := NewTranslationService(mongoURI, mongoDB, p.Collection, p.CheckInterval, translator, opts...)
+	service.pipeline = p.Name
+	service.pendingCollectionName = p.PendingCollection
+	service.cacheCollectionName = p.CacheCollection
+	if p.TargetSuffix != "" {
+		service.targetSuffix = p.TargetSuffix
+	}
+	if len(p.Fields) > 0 {
+		fields := append([]string(nil), p.Fields...)
+		listed := make(map[string]bool, len(fields))
+		for _, field := range fields {
+			listed[field] = true
+		}
+		for _, field := range service.fieldsToTranslate {
+			if _, configured := service.fieldConfigs[field]; configured && !listed[field] {
+				fields = append(fields, field)
+			}
+		}
+		service.fieldsToTranslate = fields
+	}
+	if p.BatchSize > 0 {
+		service.batchSize = p.BatchSize
+	}
+	return service, nil
+}
+
+// RunPipelines runs the services side by side, each with its own ticker and
+// connection, until all of them stopped. A pipeline that fails is logged and
+// doesn't stop the others; the failures are returned together.
+func RunPipelines(ctx context.Context, services []*TranslationService) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(services))
+	for i, service := range services {
+		wg.Add(1)
+		go func(i int, service *TranslationService) {
+			defer wg.Done()
+			if err := service.Run(ctx); err != nil {
+				log.Printf("❌ 流水线 %s 出错: %v", service.pipeline, err)
+				errs[i] = fmt.Errorf("pipeline %s: %w", service.pipeline, err)
+			}
+		}(i, service)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,127 @@
+package translation
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadPipelines(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		wantErr string
+	}{
+		{"valid", `[{"name": "ja", "pending_collection": "p1"}, {"name": "en", "pending_collection": "p2", "target_language": "English", "cache_collection": "c2"}]`, ""},
+		{"empty", `[]`, "no pipelines"},
+		{"missing pending collection", `[{"name": "ja"}]`, "required"},
+		{"duplicate name", `[{"name": "ja", "pending_collection": "p1"}, {"name": "ja", "pending_collection": "p2"}]`, "duplicate name"},
+		{"shared queue", `[{"name": "a", "pending_collection": "p"}, {"name": "b", "pending_collection": "p"}]`, "share the pending collection"},
+		{"shared cache across languages", `[{"name": "a", "pending_collection": "p1"}, {"name": "b", "pending_collection": "p2", "target_language": "English"}]`, "already holds"},
+		{"language without a suffix", `[{"name": "a", "pending_collection": "p1", "target_language": "Thai"}]`, "target_suffix is required"},
+		{"shared suffix across languages", `[{"name": "a", "pending_collection": "p1"}, {"name": "b", "pending_collection": "p2", "cache_collection": "c2", "target_language": "English", "target_suffix": "CN"}]`, "already use the target suffix"},
+		{"local provider without a model", `[{"name": "a", "pending_collection": "p1", "provider": "local"}]`, "model is required"},
+		{"unknown provider", `[{"name": "a", "pending_collection": "p1", "provider": "gpt"}]`, "unknown provider"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "pipelines.json")
+			if err := os.WriteFile(path, []byte(tt.file), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			pipelines, err := loadPipelines(path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("loadPipelines: %v", err)
+				}
+				if pipelines[0].CacheCollection != defaultCacheCollection || pipelines[0].TargetLanguage != defaultTargetLanguage || pipelines[0].TargetSuffix != "CN" {
+					t.Errorf("defaults not applied: %+v", pipelines[0])
+				}
+				if pipelines[1].TargetSuffix != "EN" {
+					t.Errorf("target suffix = %q, want EN for English", pipelines[1].TargetSuffix)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadPipelinesProviderDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pipelines.json")
+	file := `[{"name": "ja", "pending_collection": "p1", "provider": "deepseek"}, {"name": "en", "pending_collection": "p2", "cache_collection": "c2", "target_language": "English", "provider": "local", "model": "qwen"}]`
+	if err := os.WriteFile(path, []byte(file), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pipelines, err := loadPipelines(path)
+	if err != nil {
+		t.Fatalf("loadPipelines: %v", err)
+	}
+	if p := pipelines[0]; p.APIBase != defaultDeepSeekBase || p.Model != defaultDeepSeekModel {
+		t.Errorf("deepseek pipeline = %q, %q, want the provider defaults", p.APIBase, p.Model)
+	}
+	if p := pipelines[1]; p.APIBase != defaultLocalBase || p.Model != "qwen" {
+		t.Errorf("local pipeline = %q, %q, want the default base and its model", p.APIBase, p.Model)
+	}
+}
+
+func TestNewPipelineServiceAppliesSharedSettings(t *testing.T) {
+	p := PipelineConfig{
+		Name:              "en",
+		PendingCollection: "pending_en",
+		CacheCollection:   "cache_en",
+		TargetLanguage:    "English",
+		TargetSuffix:      "EN",
+		Provider:          "local",
+		Model:             "test-model",
+		Fields:            []string{"name"},
+		BatchSize:         5,
+	}
+	defaults := PipelineConfig{Collection: "toys", BatchSize: 20, CheckInterval: 60}
+
+	configureTranslator := func(translator Translator) {
+		dt := translator.(*DeepSeekTranslator)
+		dt.maxRetries = 7
+		dt.prompts.target = "Chinese"
+	}
+	shared := func(ts *TranslationService) {
+		ts.pendingDisposition = "mark"
+		ts.batchSize = 50
+		ts.statusField = "translation_status"
+	}
+	cliField := WithFieldConfig("maker", FieldConfig{Target: "makerZH"})
+	service, err := newPipelineService(p, defaults, "mongodb://localhost", "db", configureTranslator, cliField, shared)
+	if err != nil {
+		t.Fatalf("newPipelineService: %v", err)
+	}
+
+	dt := service.translator.(*DeepSeekTranslator)
+	if dt.maxRetries != 7 {
+		t.Errorf("maxRetries = %d, want the shared 7", dt.maxRetries)
+	}
+	if dt.prompts.target != "English" {
+		t.Errorf("target language = %q, want the pipeline's English", dt.prompts.target)
+	}
+	if service.pendingDisposition != "mark" || service.statusField != "translation_status" {
+		t.Errorf("shared service settings not applied: %q, %q", service.pendingDisposition, service.statusField)
+	}
+	if service.batchSize != 5 {
+		t.Errorf("batchSize = %d, want the pipeline's 5", service.batchSize)
+	}
+	if service.mongoCollection != "toys" || service.checkInterval != 60 {
+		t.Errorf("defaults not applied: %q, %d", service.mongoCollection, service.checkInterval)
+	}
+	if service.pendingCollectionName != "pending_en" || service.cacheCollectionName != "cache_en" {
+		t.Errorf("collections = %q, %q", service.pendingCollectionName, service.cacheCollectionName)
+	}
+	if !reflect.DeepEqual(service.fieldsToTranslate, []string{"name", "maker"}) {
+		t.Errorf("fields = %v, want the pipeline's name and the configured maker", service.fieldsToTranslate)
+	}
+	if service.targetKey("name") != "nameEN" || service.targetKey("maker") != "makerZH" {
+		t.Errorf("targets = %q, %q, want nameEN and the configured makerZH", service.targetKey("name"), service.targetKey("maker"))
+	}
+}
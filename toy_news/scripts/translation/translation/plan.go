@@ -0,0 +1,142 @@
+package translation
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// FieldPlan represents the projected work for one field
+type FieldPlan struct {
+	Texts     int // non-empty source texts
+	CacheHits int // texts already cached, or translated by an earlier batch
+	Unique    int // distinct texts sent to the API
+	APICalls  int
+	Tokens    int // estimated prompt tokens of the texts sent
+}
+
+// ProcessingPlan represents what processing the pending queue would cost
+type ProcessingPlan struct {
+	Items  int
+	Fields map[string]*FieldPlan
+}
+
+// PlanProcessing reads up to limit pending items (0 reads the whole queue) in
+// processing order and projects the cache hits, API calls and tokens of
+// processing them in batchSize chunks. Nothing is translated or written.
+func (ts *TranslationService) PlanProcessing(ctx context.Context, limit int) (*ProcessingPlan, error) {
+	opts := options.Find().SetSort(pendingSort).SetBatchSize(int32(ts.batchSize))
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	cursor, err := ts.pendingCollection.Find(ctx, ts.processingFilter(), opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding pending items: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	plan := &ProcessingPlan{Fields: make(map[string]*FieldPlan)}
+	for _, field := range ts.fieldsToTranslate {
+		plan.Fields[field] = &FieldPlan{}
+	}
+
+	// Texts of earlier chunks are cached by the time later chunks run
+	planned := make(map[string]map[string]bool)
+	var chunk []PendingItem
+	flush := func() error {
+		for _, field := range ts.fieldsToTranslate {
+			if planned[field] == nil {
+				planned[field] = make(map[string]bool)
+			}
+			fp := plan.Fields[field]
+			var misses []string
+			for _, item := range chunk {
+				text, _ := (&TranslatedItem{PendingItem: item}).fieldValues(field)
+				if text == "" {
+					continue
+				}
+				fp.Texts++
+				text, _ = ts.stripBoilerplate(field, text)
+				if planned[field][text] {
+					fp.CacheHits++
+					continue
+				}
+				cached, err := ts.GetCachedTranslation(ctx, field, text)
+				if err != nil {
+					return err
+				}
+				if cached != "" {
+					fp.CacheHits++
+					continue
+				}
+				planned[field][text] = true
+				misses = append(misses, text)
+				fp.Tokens += estimateTokens(text)
+			}
+			fp.Unique += len(misses)
+			fp.APICalls += ts.projectedCalls(field, misses)
+		}
+		chunk = nil
+		return nil
+	}
+
+	for cursor.Next(ctx) {
+		var item PendingItem
+		err = cursor.Decode(&item)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding pending item: %w", err)
+		}
+		plan.Items++
+		chunk = append(chunk, item)
+		if len(chunk) >= ts.batchSize {
+			if err = flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err = cursor.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating pending items: %w", err)
+	}
+	if err = flush(); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// projectedCalls returns how many API calls translating texts of one field
+// takes, ignoring retries and truncation splits
+func (ts *TranslationService) projectedCalls(field string, texts []string) int {
+	if len(texts) == 0 {
+		return 0
+	}
+	if dt, ok := ts.translator.(*DeepSeekTranslator); ok {
+		return len(dt.splitForAPI(field, texts))
+	}
+	if ensemble, ok := ts.translator.(*EnsembleTranslator); ok {
+		return len(ensemble.members)
+	}
+	return 1
+}
+
+// PrintPlan prints the projected work per field followed by the totals. The
+// cost assumes the translations take about as many tokens as the sources and
+// leaves out the prompt overhead, so it is a lower bound.
+func (ts *TranslationService) PrintPlan(plan *ProcessingPlan) {
+	fmt.Printf("📋 处理计划: %d 个待翻译项目 (每批 %d)\n", plan.Items, ts.batchSize)
+
+	var calls, tokens int
+	for _, field := range ts.fieldsToTranslate {
+		fp := plan.Fields[field]
+		fmt.Printf("  %s: %d 个文本, 缓存命中 %d, API翻译 %d 个唯一文本, %d 次调用, ~%d tokens\n",
+			field, fp.Texts, fp.CacheHits, fp.Unique, fp.APICalls, fp.Tokens)
+		calls += fp.APICalls
+		tokens += fp.Tokens
+	}
+	fmt.Printf("  合计: %d 次API调用, ~%d 输入 tokens + ~%d 输出 tokens\n", calls, tokens, tokens)
+
+	if u := ts.usage(); u != nil {
+		cost := float64(tokens)*u.inputPrice/1e6 + float64(tokens)*u.outputPrice/1e6
+		fmt.Printf("  预计费用: ≥ $%.4f\n", cost)
+	}
+}
@@ -0,0 +1,39 @@
+package translation
+
+import "testing"
+
+func TestProjectedCalls(t *testing.T) {
+	texts := []string{"a", "b", "c", "d", "e"}
+	batched := func(subBatch int, fieldSubBatch map[string]int) *DeepSeekTranslator {
+		dt := newOfflineTranslator(t)
+		dt.subBatchSize = subBatch
+		dt.fieldSubBatch = fieldSubBatch
+		return dt
+	}
+	ensemble, err := NewEnsembleTranslator([]Translator{StubTranslator{}, StubTranslator{}, StubTranslator{}}, "length")
+	if err != nil {
+		t.Fatalf("NewEnsembleTranslator: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		translator Translator
+		texts      []string
+		want       int
+	}{
+		{"nothing to translate", batched(2, nil), nil, 0},
+		{"one call without sub-batches", batched(0, nil), texts, 1},
+		{"sub-batches", batched(2, nil), texts, 3},
+		{"field override", batched(2, map[string]int{"name": 5}), texts, 1},
+		{"one call per ensemble member", ensemble, texts, 3},
+		{"other translators", StubTranslator{}, texts, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := &TranslationService{translator: tt.translator}
+			if got := ts.projectedCalls("name", tt.texts); got != tt.want {
+				t.Errorf("projectedCalls() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
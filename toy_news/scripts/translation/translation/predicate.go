@@ -0,0 +1,88 @@
+package translation
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// FieldPredicate decides whether a field of a pending item needs translating.
+// Items already in the target language or flagged not to be translated can
+// answer false; their field is then left alone as if it had no source text.
+type FieldPredicate func(item PendingItem, field string) bool
+
+// SetFieldPredicate sets the predicate consulted for every field of every
+// processed item, nil translates every field with source text
+func (ts *TranslationService) SetFieldPredicate(predicate FieldPredicate) {
+	ts.fieldPredicate = predicate
+}
+
+// skipRule skips a field, or every field when field is empty, of items whose
+// document has value at path
+type skipRule struct {
+	field string
+	path  string
+	value string
+}
+
+// parseSkipRules parses skip rules given as [field:]path=value, such as
+// noTranslate=true or description:sourceLang=zh
+func parseSkipRules(specs []string) ([]skipRule, error) {
+	var rules []skipRule
+	for _, spec := range specs {
+		condition, value, ok := strings.Cut(spec, "=")
+		field, path, scoped := strings.Cut(condition, ":")
+		if !scoped {
+			field, path = "", condition
+		}
+		if !ok || path == "" || (scoped && field == "") {
+			return nil, fmt.Errorf("expected [field:]path=value, got %q", spec)
+		}
+		rules = append(rules, skipRule{field: field, path: path, value: value})
+	}
+	return rules, nil
+}
+
+// skipRulesPredicate returns a predicate skipping the fields the rules match
+func skipRulesPredicate(rules []skipRule) FieldPredicate {
+	return func(item PendingItem, field string) bool {
+		for _, rule := range rules {
+			if rule.field != "" && rule.field != field {
+				continue
+			}
+			if value, ok := lookupValue(item.Extra, rule.path); ok && fmt.Sprint(value) == rule.value {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// applyFieldPredicate marks the fields the predicate rejects as skipped. It
+// returns the items with fields left to translate and the product hashes of
+// the items with none, which have nothing left to do.
+func (ts *TranslationService) applyFieldPredicate(items []PendingItem) ([]PendingItem, []string) {
+	if ts.fieldPredicate == nil {
+		return items, nil
+	}
+
+	var remaining []PendingItem
+	var done []string
+	for _, item := range items {
+		for _, field := range ts.fieldsToTranslate {
+			if !ts.fieldPredicate(item, field) {
+				if item.skip == nil {
+					item.skip = make(map[string]bool)
+				}
+				item.skip[field] = true
+				log.Printf("  ⏭️ %s 的 %s 无需翻译，跳过", item.ProductHash, field)
+			}
+		}
+		if !ts.hasSourceText(item) {
+			done = append(done, item.ProductHash)
+			continue
+		}
+		remaining = append(remaining, item)
+	}
+	return remaining, done
+}
@@ -0,0 +1,83 @@
+package translation
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// defaultInstructions is the system prompt used when no override is configured
+const defaultInstructions = "You are a helpful assistant that translates Japanese text to {{.Target}}."
+
+// defaultTargetLanguage is the language translated into unless a pipeline
+// names another
+const defaultTargetLanguage = "Chinese"
+
+// Output protocols appended to the instructions of every request, so custom
+// prompts can't break response parsing
+const (
+	numberedListProtocol = "Please translate each text separately and maintain the numbering. Return only the translations, one per line, with the same numbering format: '1. translation', '2. translation', etc."
+	singleTextProtocol   = "Return only the translation, without numbering, quotes or explanations."
+)
+
+// promptData is the data available to system prompt templates
+type promptData struct {
+	Field  string // field being translated, e.g. "name"
+	Target string // language translated into, e.g. "Chinese"
+}
+
+// promptSet holds the global and per-field system prompt templates and the
+// language they translate into
+type promptSet struct {
+	global *template.Template
+	fields map[string]*template.Template
+	target string
+}
+
+// newPromptSet parses the global and per-field system prompt templates. An
+// empty global prompt falls back to the built-in instructions.
+func newPromptSet(global string, fields map[string]string) (*promptSet, error) {
+	if global == "" {
+		global = defaultInstructions
+	}
+
+	ps := &promptSet{fields: make(map[string]*template.Template), target: defaultTargetLanguage}
+	tmpl, err := template.New("system").Parse(global)
+	if err != nil {
+		return nil, fmt.Errorf("invalid system prompt: %w", err)
+	}
+	ps.global = tmpl
+
+	for field, text := range fields {
+		tmpl, err := template.New(field).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s prompt: %w", field, err)
+		}
+		ps.fields[field] = tmpl
+	}
+	return ps, nil
+}
+
+// instructions renders the system prompt instructions for a field
+func (ps *promptSet) instructions(field string) (string, error) {
+	tmpl, ok := ps.fields[field]
+	if !ok {
+		tmpl = ps.global
+	}
+
+	var sb strings.Builder
+	err := tmpl.Execute(&sb, promptData{Field: field, Target: ps.target})
+	if err != nil {
+		return "", fmt.Errorf("failed to render %s prompt: %w", field, err)
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// systemPrompt renders the full system prompt for a field and protocol
+func (ps *promptSet) systemPrompt(field, protocol string) (string, error) {
+	instructions, err := ps.instructions(field)
+	if err != nil {
+		return "", err
+	}
+	return instructions + " " + protocol, nil
+}
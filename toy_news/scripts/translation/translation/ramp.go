@@ -0,0 +1,43 @@
+package translation
+
+import (
+	"log"
+	"sync"
+)
+
+// concurrencyRamp implements slow start for parallel API calls: it begins at
+// one call at a time, allows one more after each successful call up to max,
+// and halves the allowance after a failed one
+type concurrencyRamp struct {
+	mu      sync.Mutex
+	current int
+	max     int
+}
+
+// newConcurrencyRamp creates a ramp towards max parallel calls
+func newConcurrencyRamp(max int) *concurrencyRamp {
+	return &concurrencyRamp{current: 1, max: max}
+}
+
+// limit returns how many calls may run in parallel right now
+func (r *concurrencyRamp) limit() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// record adjusts the allowance after a call
+func (r *concurrencyRamp) record(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err == nil {
+		if r.current < r.max {
+			r.current++
+		}
+		return
+	}
+	if r.current > 1 {
+		r.current /= 2
+		log.Printf("🐢 API调用失败，并发降至 %d", r.current)
+	}
+}
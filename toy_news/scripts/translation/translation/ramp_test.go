@@ -0,0 +1,33 @@
+package translation
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConcurrencyRamp(t *testing.T) {
+	ramp := newConcurrencyRamp(4)
+	if got := ramp.limit(); got != 1 {
+		t.Fatalf("starting limit = %d, want 1", got)
+	}
+	failed := errors.New("503")
+	steps := []struct {
+		err  error
+		want int
+	}{
+		{nil, 2},
+		{nil, 3},
+		{nil, 4},
+		{nil, 4}, // capped at max
+		{failed, 2},
+		{failed, 1},
+		{failed, 1}, // never below one
+		{nil, 2},
+	}
+	for i, step := range steps {
+		ramp.record(step.err)
+		if got := ramp.limit(); got != step.want {
+			t.Errorf("step %d (err %v): limit = %d, want %d", i+1, step.err, got, step.want)
+		}
+	}
+}
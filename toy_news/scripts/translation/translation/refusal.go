@@ -0,0 +1,46 @@
+package translation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// defaultRefusalPatterns match common ways models refuse or apologize instead
+// of translating, in English, Chinese and Japanese. Phrases that also occur in
+// product copy, such as 不能提供发票, only count at the start of the output.
+var defaultRefusalPatterns = []string{
+	`(?i)^(i'm sorry|i am sorry|sorry,|i apologi[sz]e)`,
+	`(?i)\bi (cannot|can't|can not|am unable to|am not able to|won't) (translate|help|assist|provide)`,
+	`(?i)^as an ai\b`,
+	`^(抱歉|对不起|很抱歉|非常抱歉)`,
+	`^我?(无法|不能)(为您)?(翻译|提供|协助)`,
+	`申し訳(ありません|ございません)`,
+	`翻訳(でき|することはでき)ません`,
+}
+
+// compileRefusalPatterns compiles the default refusal patterns followed by
+// the extra ones
+func compileRefusalPatterns(extra []string) ([]*regexp.Regexp, error) {
+	var patterns []*regexp.Regexp
+	for _, pattern := range append(append([]string{}, defaultRefusalPatterns...), extra...) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid refusal pattern %q: %w", pattern, err)
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, nil
+}
+
+// isRefusal reports whether a model output looks like a refusal rather than
+// a translation
+func (ts *TranslationService) isRefusal(output string) bool {
+	output = strings.TrimSpace(output)
+	for _, re := range ts.refusalPatterns {
+		if re.MatchString(output) {
+			return true
+		}
+	}
+	return false
+}
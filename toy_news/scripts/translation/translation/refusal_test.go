@@ -0,0 +1,42 @@
+package translation
+
+import "testing"
+
+func TestIsRefusal(t *testing.T) {
+	patterns, err := compileRefusalPatterns([]string{`^N/A$`})
+	if err != nil {
+		t.Fatalf("compileRefusalPatterns: %v", err)
+	}
+	ts := &TranslationService{refusalPatterns: patterns}
+
+	tests := []struct {
+		output string
+		want   bool
+	}{
+		{"I'm sorry, but I can't translate this text.", true},
+		{"  Sorry, I cannot help with that", true},
+		{"As an AI language model, I cannot", true},
+		{"I am unable to translate the following", true},
+		{"抱歉，我无法翻译这段内容。", true},
+		{"我无法为您翻译该文本", true},
+		{"不能提供翻译", true},
+		{"申し訳ありませんが、翻訳できません", true},
+		{"N/A", true},
+		{"高达模型 1/144 比例", false},
+		{"本商品不能提供发票，敬请谅解", false},
+		{"附赠说明书，无法退货", false},
+		{"Sorry Sorry 限定版手办", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := ts.isRefusal(tt.output); got != tt.want {
+			t.Errorf("isRefusal(%q) = %v, want %v", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestCompileRefusalPatternsInvalid(t *testing.T) {
+	if _, err := compileRefusalPatterns([]string{"("}); err == nil {
+		t.Error("compileRefusalPatterns should reject an invalid pattern")
+	}
+}
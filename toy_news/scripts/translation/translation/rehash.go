@@ -0,0 +1,78 @@
+package translation
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RehashCache recomputes the text_hash of every cache entry from its
+// original_text under the current hashing, so entries stay reachable after the
+// hashing changes. An entry whose new key is already taken is merged into the
+// existing one: usage counts add up and the more recently updated translation
+// wins. It returns how many entries were re-keyed and how many merged.
+func (ts *TranslationService) RehashCache(ctx context.Context) (int, int, error) {
+	// Walking _id order keeps rewritten entries from being visited again
+	cursor, err := ts.cacheCollection.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return 0, 0, fmt.Errorf("error reading cache entries: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	rekeyed, merged := 0, 0
+	for cursor.Next(ctx) {
+		var entry CacheItem
+		err = cursor.Decode(&entry)
+		if err != nil {
+			return rekeyed, merged, fmt.Errorf("error decoding cache entry: %w", err)
+		}
+		textHash := ts.GetTextHash(entry.OriginalText)
+		if textHash == entry.TextHash {
+			continue
+		}
+
+		var existing CacheItem
+		err = ts.cacheCollection.FindOne(ctx, bson.M{"text_hash": textHash}).Decode(&existing)
+		if err == mongo.ErrNoDocuments {
+			_, err = ts.cacheCollection.UpdateByID(ctx, entry.ID, bson.M{"$set": bson.M{"text_hash": textHash}})
+			if err != nil {
+				return rekeyed, merged, &CacheError{Op: "write", TextHash: textHash, Err: err}
+			}
+			rekeyed++
+			continue
+		}
+		if err != nil {
+			return rekeyed, merged, &CacheError{Op: "read", TextHash: textHash, Err: err}
+		}
+
+		update := bson.M{"$inc": bson.M{"usage_count": entry.UsageCount}}
+		if entry.UpdatedAt.After(existing.UpdatedAt) {
+			update["$set"] = bson.M{
+				"translated_text": entry.TranslatedText,
+				"updated_at":      entry.UpdatedAt,
+				"provider":        entry.Provider,
+				"model":           entry.Model,
+				"prompt_version":  entry.PromptVersion,
+			}
+		}
+		_, err = ts.cacheCollection.UpdateByID(ctx, existing.ID, update)
+		if err != nil {
+			return rekeyed, merged, &CacheError{Op: "write", TextHash: textHash, Err: err}
+		}
+		_, err = ts.cacheCollection.DeleteOne(ctx, bson.M{"_id": entry.ID})
+		if err != nil {
+			return rekeyed, merged, &CacheError{Op: "write", TextHash: entry.TextHash, Err: err}
+		}
+		merged++
+	}
+	if err = cursor.Err(); err != nil {
+		return rekeyed, merged, fmt.Errorf("error iterating cache entries: %w", err)
+	}
+
+	log.Printf("🔑 缓存重新计算哈希: %d 条更新, %d 条合并", rekeyed, merged)
+	return rekeyed, merged, nil
+}
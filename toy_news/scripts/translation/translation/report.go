@@ -0,0 +1,99 @@
+package translation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// CycleReport represents what one processing cycle did, written as a JSONL
+// line of the -cycle-report file and/or a document of the reports collection
+type CycleReport struct {
+	Batch        string                      `json:"batch" bson:"batch"`
+	Started      time.Time                   `json:"started" bson:"started"`
+	DurationMs   int64                       `json:"duration_ms" bson:"duration_ms"`
+	Items        int                         `json:"items" bson:"items"`         // pending items picked up
+	Processed    int                         `json:"processed" bson:"processed"` // items that left the queue
+	CacheHits    int64                       `json:"cache_hits" bson:"cache_hits"`
+	CacheMisses  int64                       `json:"cache_misses" bson:"cache_misses"`
+	FieldCache   map[string]FieldCacheCounts `json:"field_cache,omitempty" bson:"field_cache,omitempty"` // the cache hits and misses by field
+	APICalls     int64                       `json:"api_calls" bson:"api_calls"`
+	ThrottleRate float64                     `json:"throttle_rate,omitempty" bson:"throttle_rate,omitempty"` // requests per second allowed by -adaptive-throttle at the end
+	Updated      []string                    `json:"updated" bson:"updated"`                                 // product hashes written to the normalized collection
+	Failures     map[string]string           `json:"failures,omitempty" bson:"failures,omitempty"`           // product hash -> why it stays pending
+	Error        string                      `json:"error,omitempty" bson:"error,omitempty"`                 // the cycle's own failure
+}
+
+// reportFile appends cycle reports to a JSONL file
+type reportFile struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// openReportFile opens path for appending cycle reports
+func openReportFile(path string) (*reportFile, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cycle report file: %w", err)
+	}
+	return &reportFile{file: file}, nil
+}
+
+// write appends report as one line
+func (f *reportFile) write(report *CycleReport) error {
+	line, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, err = f.file.Write(append(line, '\n'))
+	return err
+}
+
+// Close closes the report file
+func (f *reportFile) Close() error {
+	return f.file.Close()
+}
+
+// reportsEnabled reports whether cycle reports are written anywhere
+func (ts *TranslationService) reportsEnabled() bool {
+	return ts.reportFile != nil || ts.reportCollectionName != ""
+}
+
+// apiCalls returns how many API calls the translator made, 0 when it doesn't
+// count them
+func (ts *TranslationService) apiCalls() int64 {
+	if counting, ok := ts.translator.(apiCallCounting); ok {
+		return counting.APICalls()
+	}
+	return 0
+}
+
+// throttleRate returns the request rate the translator's throttle allows, 0
+// when it isn't throttled
+func (ts *TranslationService) throttleRate() float64 {
+	if rating, ok := ts.translator.(throttleRating); ok {
+		return rating.ThrottleRate()
+	}
+	return 0
+}
+
+// writeCycleReport writes report to the configured file and collection.
+// Failing to report must not fail the cycle, so errors are only logged.
+func (ts *TranslationService) writeCycleReport(ctx context.Context, report *CycleReport) {
+	if ts.reportFile != nil {
+		if err := ts.reportFile.write(report); err != nil {
+			log.Printf("⚠️ 无法写入周期报告: %v", err)
+		}
+	}
+	if ts.reportCollection != nil {
+		if _, err := ts.reportCollection.InsertOne(ctx, report); err != nil {
+			log.Printf("⚠️ 无法写入周期报告集合: %v", err)
+		}
+	}
+}
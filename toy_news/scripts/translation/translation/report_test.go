@@ -0,0 +1,61 @@
+package translation
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCycleReportFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cycles.jsonl")
+	file, err := openReportFile(path)
+	if err != nil {
+		t.Fatalf("openReportFile: %v", err)
+	}
+	ts := newTestService(fakeTranslator{})
+	ts.reportFile = file
+
+	reports := []*CycleReport{
+		{Batch: "b1", Items: 3, Processed: 2, CacheHits: 4, CacheMisses: 1, APICalls: 1, DurationMs: 120,
+			Updated: []string{"h1", "h2"}, Failures: map[string]string{"h3": "refused"}},
+		{Batch: "b2", Items: 1, Error: "mongo unavailable"},
+	}
+	for _, report := range reports {
+		ts.writeCycleReport(context.Background(), report)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != len(reports) {
+		t.Fatalf("%d report lines, want one per cycle", len(lines))
+	}
+	for _, key := range []string{"batch", "started", "duration_ms", "items", "processed", "cache_hits", "cache_misses", "api_calls", "updated", "failures"} {
+		if _, ok := lines[0][key]; !ok {
+			t.Errorf("report lacks %s: %v", key, lines[0])
+		}
+	}
+	if lines[0]["processed"] != 2.0 || lines[0]["failures"].(map[string]interface{})["h3"] != "refused" {
+		t.Errorf("first report = %v", lines[0])
+	}
+	if lines[1]["error"] != "mongo unavailable" {
+		t.Errorf("second report error = %v", lines[1]["error"])
+	}
+}
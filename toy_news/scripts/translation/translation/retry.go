@@ -0,0 +1,99 @@
+package translation
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Jitter strategies for retry backoff, following the formulas from the AWS
+// Architecture Blog post "Exponential Backoff And Jitter"
+const (
+	JitterNone  = "none"
+	JitterFull  = "full"
+	JitterEqual = "equal"
+)
+
+// parseJitter validates a jitter strategy name
+func parseJitter(name string) (string, error) {
+	switch name {
+	case JitterNone, JitterFull, JitterEqual:
+		return name, nil
+	}
+	return "", fmt.Errorf("unknown jitter strategy %q (expected none, full or equal)", name)
+}
+
+// backoffDelay returns how long to wait before retry number attempt (starting
+// at 0). The exponential delay base*2^attempt is capped at maxDelay and then
+// randomized according to the jitter strategy:
+//
+//	none:  delay
+//	full:  random in [0, delay]
+//	equal: delay/2 + random in [0, delay/2]
+func backoffDelay(attempt int, base, maxDelay time.Duration, jitter string, rng *rand.Rand) time.Duration {
+	delay := maxDelay
+	if attempt < 32 {
+		if exp := base << uint(attempt); exp > 0 && exp < maxDelay {
+			delay = exp
+		}
+	}
+
+	switch jitter {
+	case JitterFull:
+		return time.Duration(rng.Int63n(int64(delay) + 1))
+	case JitterEqual:
+		half := delay / 2
+		return half + time.Duration(rng.Int63n(int64(delay-half)+1))
+	default:
+		return delay
+	}
+}
+
+// retryPolicy describes how an operation is retried
+type retryPolicy struct {
+	maxRetries int // extra attempts after the first
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	jitter     string
+	rng        *rand.Rand  // required for jitter other than none
+	rngMu      sync.Locker // guards rng when it is shared, may be nil
+
+	// retryable decides whether a failure is worth another attempt, nil
+	// retries every failure
+	retryable func(error) bool
+	// onRetry is told about each failure about to be retried, may be nil
+	onRetry func(attempt int, err error, delay time.Duration)
+}
+
+// delay returns the backoff before retry number attempt
+func (p retryPolicy) delay(attempt int) time.Duration {
+	if p.rngMu != nil {
+		p.rngMu.Lock()
+		defer p.rngMu.Unlock()
+	}
+	return backoffDelay(attempt, p.baseDelay, p.maxDelay, p.jitter, p.rng)
+}
+
+// retry calls fn until it succeeds, fails with an error that isn't retryable
+// or the retries are used up, and returns its last error. Waiting between
+// attempts stops when ctx is done, returning the context's error.
+func retry(ctx context.Context, p retryPolicy, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.maxRetries || (p.retryable != nil && !p.retryable(err)) {
+			return err
+		}
+
+		delay := p.delay(attempt)
+		if p.onRetry != nil {
+			p.onRetry(attempt, err, delay)
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package translation
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	const base, maxDelay = 100 * time.Millisecond, time.Second
+	rng := rand.New(rand.NewSource(1))
+
+	tests := []struct {
+		name     string
+		attempt  int
+		jitter   string
+		min, max time.Duration
+	}{
+		{"none doubles", 0, JitterNone, base, base},
+		{"none third retry", 2, JitterNone, 4 * base, 4 * base},
+		{"none capped", 10, JitterNone, maxDelay, maxDelay},
+		{"none overflowing shift capped", 40, JitterNone, maxDelay, maxDelay},
+		{"full within delay", 2, JitterFull, 0, 4 * base},
+		{"equal keeps half", 2, JitterEqual, 2 * base, 4 * base},
+		{"equal capped", 10, JitterEqual, maxDelay / 2, maxDelay},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				got := backoffDelay(tt.attempt, base, maxDelay, tt.jitter, rng)
+				if got < tt.min || got > tt.max {
+					t.Fatalf("backoffDelay(%d, %s) = %s, want in [%s, %s]", tt.attempt, tt.jitter, got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestParseJitter(t *testing.T) {
+	for _, name := range []string{JitterNone, JitterFull, JitterEqual} {
+		if got, err := parseJitter(name); err != nil || got != name {
+			t.Errorf("parseJitter(%q) = %q, %v", name, got, err)
+		}
+	}
+	if _, err := parseJitter("decorrelated"); err == nil {
+		t.Error("parseJitter(decorrelated) should fail")
+	}
+}
+
+func TestRetry(t *testing.T) {
+	errTransient := errors.New("transient")
+	errPermanent := errors.New("permanent")
+
+	tests := []struct {
+		name         string
+		maxRetries   int
+		failures     []error // returned by the attempts in turn, then success
+		wantErr      error
+		wantAttempts int
+	}{
+		{"first attempt succeeds", 3, nil, nil, 1},
+		{"succeeds after retries", 3, []error{errTransient, errTransient}, nil, 3},
+		{"retries used up", 2, []error{errTransient, errTransient, errTransient, errTransient}, errTransient, 3},
+		{"no retries", 0, []error{errTransient}, errTransient, 1},
+		{"not retryable", 3, []error{errPermanent}, errPermanent, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retried := 0
+			policy := retryPolicy{
+				maxRetries: tt.maxRetries,
+				baseDelay:  time.Millisecond,
+				maxDelay:   time.Millisecond,
+				jitter:     JitterNone,
+				retryable:  func(err error) bool { return err == errTransient },
+				onRetry:    func(int, error, time.Duration) { retried++ },
+			}
+			attempts := 0
+			err := retry(context.Background(), policy, func() error {
+				attempts++
+				if attempts <= len(tt.failures) {
+					return tt.failures[attempts-1]
+				}
+				return nil
+			})
+			if err != tt.wantErr {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts || retried != attempts-1 {
+				t.Errorf("attempts = %d, retried = %d, want %d attempts", attempts, retried, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRetryStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := retryPolicy{maxRetries: 5, baseDelay: time.Hour, maxDelay: time.Hour, jitter: JitterNone}
+	attempts := 0
+	err := retry(ctx, policy, func() error {
+		attempts++
+		cancel()
+		return errors.New("down")
+	})
+	if !errors.Is(err, context.Canceled) || attempts != 1 {
+		t.Errorf("err = %v after %d attempts, want context.Canceled after 1", err, attempts)
+	}
+}
@@ -0,0 +1,132 @@
+package translation
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// reviewCollectionName holds translations waiting for human review before
+// they go live
+const reviewCollectionName = "toys_translation_review"
+
+// ReviewItem represents translations held back for review. Setting Approved
+// lets -promote-reviewed write Updates to the normalized collection.
+type ReviewItem struct {
+	ProductHash string    `bson:"product_hash"`
+	Updates     bson.M    `bson:"updates"`
+	Reasons     []string  `bson:"reasons"`
+	Approved    bool      `bson:"approved"`
+	CreatedAt   time.Time `bson:"createdAt"`
+}
+
+// reviewThresholds decide which translations need review (zero disables)
+type reviewThresholds struct {
+	maxChars int     // characters of a translation
+	maxRatio float64 // characters of a translation per character of its source
+}
+
+// enabled reports whether any threshold is set
+func (t reviewThresholds) enabled() bool {
+	return t.maxChars > 0 || t.maxRatio > 0
+}
+
+// reviewReasons returns why the translations of an item need review, none
+// when they can go live
+func (ts *TranslationService) reviewReasons(item *TranslatedItem) []string {
+	var reasons []string
+	for _, field := range ts.fieldsToTranslate {
+		source, translation := item.fieldValues(field)
+		if translation == "" {
+			continue
+		}
+		chars := utf8.RuneCountInString(translation)
+		if ts.review.maxChars > 0 && chars > ts.review.maxChars {
+			reasons = append(reasons, fmt.Sprintf("%s: %d characters (max %d)", field, chars, ts.review.maxChars))
+		}
+		sourceChars := utf8.RuneCountInString(source)
+		if ts.review.maxRatio > 0 && sourceChars > 0 {
+			if ratio := float64(chars) / float64(sourceChars); ratio > ts.review.maxRatio {
+				reasons = append(reasons, fmt.Sprintf("%s: length ratio %.2f (max %.2f)", field, ratio, ts.review.maxRatio))
+			}
+		}
+	}
+	return reasons
+}
+
+// sendToReview stores the operations in the review collection instead of the
+// normalized collection. An earlier review of the same product is kept as it
+// is, so a reviewer's approval is never reset or applied to translations the
+// reviewer hasn't seen.
+func (ts *TranslationService) sendToReview(ctx context.Context, ops []UpdateOperation, reasons map[string][]string) error {
+	if len(ops) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	var models []mongo.WriteModel
+	for _, op := range ops {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"product_hash": op.ProductHash}).
+			SetUpdate(bson.M{"$setOnInsert": ReviewItem{
+				ProductHash: op.ProductHash,
+				Updates:     op.Updates,
+				Reasons:     reasons[op.ProductHash],
+				CreatedAt:   now,
+			}}).
+			SetUpsert(true))
+	}
+	result, err := ts.bulkWrite(ctx, ts.reviewCollection, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return fmt.Errorf("failed to store translations for review: %w", err)
+	}
+	log.Printf("🔍 %d 个项目的翻译需要人工审核，已写入 %s (%d 个已在审核中，保持不变)",
+		len(ops), reviewCollectionName, int64(len(ops))-result.UpsertedCount)
+	return nil
+}
+
+// PromoteReviewed writes the approved translations of the review collection
+// to the normalized collection and removes them from review. It returns the
+// number of products promoted.
+func (ts *TranslationService) PromoteReviewed(ctx context.Context) (int, error) {
+	cursor, err := ts.reviewCollection.Find(ctx, bson.M{"approved": true})
+	if err != nil {
+		return 0, fmt.Errorf("error finding approved translations: %w", err)
+	}
+	var items []ReviewItem
+	err = cursor.All(ctx, &items)
+	if err != nil {
+		return 0, fmt.Errorf("error decoding approved translations: %w", err)
+	}
+	if len(items) == 0 {
+		log.Printf("No approved translations to promote")
+		return 0, nil
+	}
+
+	var models []mongo.WriteModel
+	var hashes []string
+	for _, item := range items {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"product_hash": item.ProductHash}).
+			SetUpdate(ts.normalizedUpdate(item.Updates)))
+		hashes = append(hashes, item.ProductHash)
+	}
+	result, err := ts.bulkWrite(ctx, ts.normalizedCollection, models)
+	if err != nil {
+		return 0, fmt.Errorf("error promoting approved translations: %w", err)
+	}
+
+	_, err = ts.deleteMany(ctx, ts.reviewCollection, bson.M{"product_hash": bson.M{"$in": hashes}, "approved": true})
+	if err != nil {
+		return 0, fmt.Errorf("error removing promoted translations from review: %w", err)
+	}
+
+	log.Printf("✅ Promoted %d reviewed translations (%d products matched)", len(items), result.MatchedCount)
+	return len(items), nil
+}
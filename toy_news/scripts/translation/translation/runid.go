@@ -0,0 +1,56 @@
+package translation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+)
+
+// instanceIDEnv names the environment variable identifying this instance in
+// the logs and metrics, e.g. the pod or host name
+const instanceIDEnv = "TRANSLATION_INSTANCE_ID"
+
+// newRunID returns a random identifier of this run, falling back to the
+// start time should the system have no randomness to offer
+func newRunID() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// logPrefix returns the prefix put on every log line, so lines of concurrent
+// runs logging to a shared aggregator can be told apart
+func logPrefix(runID string) string {
+	if instance := os.Getenv(instanceIDEnv); instance != "" {
+		return fmt.Sprintf("[run=%s instance=%s] ", runID, instance)
+	}
+	return fmt.Sprintf("[run=%s] ", runID)
+}
+
+// batchIDKey is the context key of the ID of the batch being processed
+type batchIDKey struct{}
+
+// withBatchID returns ctx carrying the ID of the batch it processes, so the
+// API calls made for the batch can be traced back to it in the logs
+func withBatchID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, batchIDKey{}, id)
+}
+
+// batchID returns the batch ID carried by ctx, "-" outside of a batch
+func batchID(ctx context.Context) string {
+	if id, ok := ctx.Value(batchIDKey{}).(string); ok {
+		return id
+	}
+	return "-"
+}
+
+// requestID identifies one attempt of an API call: the batch, the call
+// number and the attempt number (1 for the first try)
+func requestID(batch string, call int64, attempt int) string {
+	return fmt.Sprintf("%s-%d-%d", batch, call, attempt)
+}
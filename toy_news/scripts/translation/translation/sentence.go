@@ -0,0 +1,101 @@
+package translation
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"unicode"
+)
+
+// sentenceTerminators end a sentence; closing brackets right after one still
+// belong to the sentence
+const (
+	sentenceTerminators = "。！？!?"
+	sentenceClosers     = "」』）)】"
+)
+
+// sentence is one sentence of a text and the whitespace that followed it
+type sentence struct {
+	text string
+	sep  string
+}
+
+// splitSentences cuts text into sentences at Japanese and Western sentence
+// ends. Concatenating the texts and separators gives back text.
+func splitSentences(text string) []sentence {
+	var sentences []sentence
+	runes := []rune(text)
+	start := 0
+	for i := 0; i < len(runes); i++ {
+		if !strings.ContainsRune(sentenceTerminators, runes[i]) {
+			continue
+		}
+		end := i + 1
+		for end < len(runes) && strings.ContainsRune(sentenceTerminators+sentenceClosers, runes[end]) {
+			end++
+		}
+		sepEnd := end
+		for sepEnd < len(runes) && unicode.IsSpace(runes[sepEnd]) {
+			sepEnd++
+		}
+		sentences = append(sentences, sentence{text: string(runes[start:end]), sep: string(runes[end:sepEnd])})
+		start = sepEnd
+		i = sepEnd - 1
+	}
+	if start < len(runes) {
+		rest := string(runes[start:])
+		trimmed := strings.TrimRightFunc(rest, unicode.IsSpace)
+		sentences = append(sentences, sentence{text: trimmed, sep: rest[len(trimmed):]})
+	}
+	return sentences
+}
+
+// sentenceSplit is a text of an item that is translated sentence by sentence
+type sentenceSplit struct {
+	index     int // of the item in the batch
+	sentences []sentence
+}
+
+// translateSentences translates the split texts of field sentence by
+// sentence, each sentence cached on its own, and puts the translations back
+// together on their items. A text with any sentence left untranslated gets no
+// translation; one whose sentences all came back as they were is caught as
+// equal to its source when the item is stored.
+func (ts *TranslationService) translateSentences(ctx context.Context, field string, items []TranslatedItem, splits []sentenceSplit, affixes []affix) {
+	var texts []string
+	for _, split := range splits {
+		for _, s := range split.sentences {
+			texts = append(texts, s.text)
+		}
+	}
+	log.Printf("✂️  %s: %d 个文本拆分为 %d 个句子翻译", field, len(splits), len(texts))
+
+	translations, untranslated, err := ts.translateParts(ctx, field, texts, true)
+	if err != nil && !errors.Is(err, ErrCountMismatch) {
+		log.Printf("Error translating sentences: %v", err)
+		for _, split := range splits {
+			items[split.index].setFieldFailure(field, err)
+		}
+		return
+	}
+
+	next := 0
+	for _, split := range splits {
+		var sb strings.Builder
+		complete := true
+		for _, s := range split.sentences {
+			if untranslated[next] {
+				complete = false
+			}
+			sb.WriteString(translations[next])
+			sb.WriteString(s.sep)
+			next++
+		}
+		if !complete {
+			items[split.index].setFieldError(field, "sentences left untranslated")
+			continue
+		}
+		items[split.index].setTranslation(field, affixes[split.index].wrap(sb.String()))
+	}
+}
@@ -0,0 +1,59 @@
+package translation
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSplitSentences(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []sentence
+	}{
+		{"single sentence", "ガンダムです", []sentence{{"ガンダムです", ""}}},
+		{"japanese ends", "新作です。予約受付中！", []sentence{{"新作です。", ""}, {"予約受付中！", ""}}},
+		{"closing bracket stays", "「限定版。」再販なし。", []sentence{{"「限定版。」", ""}, {"再販なし。", ""}}},
+		{"western ends keep spacing", "Limited! Order now?  Yes\n", []sentence{{"Limited!", " "}, {"Order now?", "  "}, {"Yes", "\n"}}},
+		{"repeated terminators", "本当に！？はい。", []sentence{{"本当に！？", ""}, {"はい。", ""}}},
+		{"empty", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitSentences(tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitSentences(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+			joined := ""
+			for _, s := range got {
+				joined += s.text + s.sep
+			}
+			if joined != tt.text {
+				t.Errorf("sentences join to %q, want %q", joined, tt.text)
+			}
+		})
+	}
+}
+
+func TestTranslateSentencesKeepsIdenticalSentence(t *testing.T) {
+	translator := fakeTranslator{answers: map[string]string{
+		"新作です。":    "新品。",
+		"RX-78-2.": "RX-78-2.",
+	}}
+	ts := newTestService(translator, WithFieldConfig("description", FieldConfig{NoCache: true}))
+	text := "新作です。RX-78-2."
+	splits := []sentenceSplit{{index: 0, sentences: splitSentences(text)}}
+	items := []TranslatedItem{{}}
+
+	ts.translateSentences(context.Background(), "description", items, splits, make([]affix, 1))
+	if _, got := items[0].fieldValues("description"); got != "新品。RX-78-2." {
+		t.Errorf("translation = %q, want the identical sentence kept", got)
+	}
+	if ts.isIdentity("description", text, "新品。RX-78-2.") {
+		t.Error("the whole text differs from its source")
+	}
+	if !ts.isIdentity("description", text, text) {
+		t.Error("a text whose sentences all stayed as they were equals its source")
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package translation
+
+import (
+	"os"
+	"syscall"
+)
+
+// SIGUSR1 toggles pausing and SIGUSR2 resumes processing
+var (
+	pauseSignal  os.Signal = syscall.SIGUSR1
+	resumeSignal os.Signal = syscall.SIGUSR2
+)
+
+// controlSignals returns the signals that pause and resume processing
+func controlSignals() []os.Signal {
+	return []os.Signal{pauseSignal, resumeSignal}
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+package translation
+
+import "os"
+
+// Windows has no user signals, use -pause-file to pause processing instead
+var (
+	pauseSignal  os.Signal
+	resumeSignal os.Signal
+)
+
+// controlSignals returns the signals that pause and resume processing
+func controlSignals() []os.Signal {
+	return nil
+}
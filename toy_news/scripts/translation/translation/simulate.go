@@ -0,0 +1,83 @@
+package translation
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NormalizationReport represents how the cache would shrink if its keys were
+// computed from normalized source texts
+type NormalizationReport struct {
+	Entries   int        // cache entries scanned
+	Keys      int        // distinct keys after normalization
+	Collapsed int        // entries that would share a key with another entry
+	Groups    int        // keys shared by more than one entry
+	Lookups   int64      // usage counts of the scanned entries
+	Examples  [][]string // original texts of the first shared keys
+}
+
+// maxNormalizationExamples caps the groups of near-duplicates reported
+const maxNormalizationExamples = 5
+
+// SimulateNormalization scans the original texts of the cache and reports how
+// many entries would collapse into shared keys under normalizeSourceKey.
+// Every collapsed entry was created by an API call that a normalized key would
+// have answered from the cache. Nothing is written.
+func (ts *TranslationService) SimulateNormalization(ctx context.Context) (*NormalizationReport, error) {
+	opts := options.Find().SetProjection(bson.M{"original_text": 1, "usage_count": 1})
+	cursor, err := ts.cacheCollection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error reading cache entries: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	report := &NormalizationReport{}
+	groups := make(map[string][]string)
+	var order []string
+	for cursor.Next(ctx) {
+		var entry CacheItem
+		if err := cursor.Decode(&entry); err != nil {
+			return nil, fmt.Errorf("error decoding cache entry: %w", err)
+		}
+		report.Entries++
+		report.Lookups += int64(entry.UsageCount)
+
+		key := normalizeSourceKey(entry.OriginalText)
+		if _, seen := groups[key]; !seen {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], entry.OriginalText)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating cache entries: %w", err)
+	}
+
+	report.Keys = len(groups)
+	report.Collapsed = report.Entries - report.Keys
+	for _, key := range order {
+		if texts := groups[key]; len(texts) > 1 {
+			report.Groups++
+			if len(report.Examples) < maxNormalizationExamples {
+				report.Examples = append(report.Examples, texts)
+			}
+		}
+	}
+	return report, nil
+}
+
+// PrintNormalizationReport prints the projected effect of normalized keys.
+// The extra hit rate puts the API calls saved against all recorded lookups.
+func PrintNormalizationReport(report *NormalizationReport) {
+	fmt.Printf("🔍 缓存规范化模拟: %d 条缓存, 规范化后 %d 个键\n", report.Entries, report.Keys)
+	fmt.Printf("  可合并: %d 条 (%d 组近似重复)\n", report.Collapsed, report.Groups)
+	if report.Lookups > 0 {
+		fmt.Printf("  预计额外命中率: +%.1f%% (%d / %d 次使用)\n",
+			float64(report.Collapsed)*100/float64(report.Lookups), report.Collapsed, report.Lookups)
+	}
+	for _, texts := range report.Examples {
+		fmt.Printf("  例: %q\n", texts)
+	}
+}
@@ -0,0 +1,46 @@
+package translation
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+)
+
+// statsCount runs one stats query with the stats timeout, retrying it
+// statsRetries times. It reports false when the count couldn't be fetched.
+func (ts *TranslationService) statsCount(ctx context.Context, what string, query func(context.Context) (int64, error)) (int64, bool) {
+	policy := retryPolicy{
+		maxRetries: ts.statsRetries,
+		baseDelay:  500 * time.Millisecond,
+		maxDelay:   5 * time.Second,
+		jitter:     JitterNone,
+		// A query that timed out on its own is retried, a cancelled run isn't
+		retryable: func(error) bool { return ctx.Err() == nil },
+	}
+
+	var count int64
+	err := retry(ctx, policy, func() error {
+		queryCtx, cancel := ctx, context.CancelFunc(func() {})
+		if ts.statsTimeout > 0 {
+			queryCtx, cancel = context.WithTimeout(ctx, ts.statsTimeout)
+		}
+		defer cancel()
+		var err error
+		count, err = query(queryCtx)
+		return err
+	})
+	if err == nil {
+		return count, true
+	}
+	log.Printf("⚠️  统计查询失败 (%s): %v", what, err)
+	return 0, false
+}
+
+// formatCount formats a stats count, marking counts that couldn't be fetched
+func formatCount(count int64, ok bool) string {
+	if !ok {
+		return "n/a"
+	}
+	return strconv.FormatInt(count, 10)
+}
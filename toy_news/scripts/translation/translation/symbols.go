@@ -0,0 +1,101 @@
+package translation
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// defaultSymbolPattern matches emoji, including modifier and ZWJ sequences,
+// and the dingbats and trademark signs product names tend to carry
+const defaultSymbolPattern = `(?:[\x{1F000}-\x{1FAFF}\x{2600}-\x{27BF}\x{2300}-\x{23FF}\x{2B00}-\x{2BFF}\x{00A9}\x{00AE}\x{2122}][\x{FE0F}\x{200D}\x{1F3FB}-\x{1F3FF}]*)+`
+
+// defaultMeasurementPattern matches measurements such as 180mm, 1.5 kg,
+// 10×20cm, 50% or 1/7 scales, whose numbers and units must come back
+// unchanged. A letter unit must end the word; % needs no word boundary after
+// it, since a space or bracket usually follows.
+const defaultMeasurementPattern = `[0-9]+(?:[.,][0-9]+)?(?:\s*[x×~〜-]\s*[0-9]+(?:[.,][0-9]+)?)*\s*(?:(?:mm|cm|km|kg|mg|ml|mL|mAh|inch|cc|m|g|L|V|W)\b|%)|1/[0-9]+\b`
+
+// symbolMasker swaps emoji and other symbols the model tends to drop or
+// alter for numbered placeholders before translation and puts them back
+// afterwards
+type symbolMasker struct {
+	re *regexp.Regexp
+}
+
+// newSymbolMasker creates a masker for the default symbols and/or
+// measurements plus the extra patterns
+func newSymbolMasker(symbols, measurements bool, patterns []string) (*symbolMasker, error) {
+	var alternatives []string
+	if measurements {
+		alternatives = append(alternatives, defaultMeasurementPattern)
+	}
+	if symbols {
+		alternatives = append(alternatives, defaultSymbolPattern)
+	}
+	for _, pattern := range patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid symbol pattern %q: %w", pattern, err)
+		}
+		alternatives = append(alternatives, "(?:"+pattern+")")
+	}
+	return &symbolMasker{re: regexp.MustCompile(strings.Join(alternatives, "|"))}, nil
+}
+
+// symbolPlaceholder returns the stand-in of the n-th symbol of a text
+func symbolPlaceholder(n int) string {
+	return fmt.Sprintf("⟦%d⟧", n)
+}
+
+// mask replaces the symbols of texts with placeholders. It returns the texts
+// to send and the symbols taken out of each, in order. A nil masker sends the
+// texts unchanged.
+func (m *symbolMasker) mask(texts []string) ([]string, [][]string) {
+	if m == nil {
+		return texts, nil
+	}
+
+	sent := make([]string, len(texts))
+	symbols := make([][]string, len(texts))
+	for i, text := range texts {
+		sent[i] = m.re.ReplaceAllStringFunc(text, func(symbol string) string {
+			symbols[i] = append(symbols[i], symbol)
+			return symbolPlaceholder(len(symbols[i]) - 1)
+		})
+	}
+	return sent, symbols
+}
+
+// instruction returns the prompt addition asking to keep the placeholders,
+// or "" when none of texts has any
+func (m *symbolMasker) instruction(texts []string) string {
+	if m == nil {
+		return ""
+	}
+	for _, text := range texts {
+		if strings.Contains(text, symbolPlaceholder(0)) {
+			return "\nKeep every ⟦n⟧ placeholder unchanged at the matching position."
+		}
+	}
+	return ""
+}
+
+// restore puts the masked symbols back into the translations. A placeholder
+// the model dropped gets its symbol appended at the end, so it is never lost.
+func (m *symbolMasker) restore(translations []string, symbols [][]string) {
+	for i := range translations {
+		if i >= len(symbols) {
+			break
+		}
+		for n, symbol := range symbols[i] {
+			placeholder := symbolPlaceholder(n)
+			if strings.Contains(translations[i], placeholder) {
+				translations[i] = strings.Replace(translations[i], placeholder, symbol, 1)
+				continue
+			}
+			log.Printf("⚠️ 翻译丢失了符号 %s，追加到末尾: %s", symbol, translations[i])
+			translations[i] += symbol
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package translation
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSymbolMaskerKeepsEmoji(t *testing.T) {
+	tests := []struct {
+		name   string
+		answer func(texts []string) string
+		want   string
+	}{
+		{"placeholder kept", numberedAnswer, "译:⟦0⟧ガンダム⟦1⟧"},
+		{"placeholder dropped", func(texts []string) string { return "1. 高达" }, "高达⟦0⟧⟦1⟧"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dt, api := newFakeAPI(t, func(call int, texts []string) (int, string, string) {
+				return http.StatusOK, tt.answer(texts), "stop"
+			})
+			masker, err := newSymbolMasker(true, false, nil)
+			if err != nil {
+				t.Fatalf("newSymbolMasker: %v", err)
+			}
+			dt.symbols = masker
+
+			got, err := dt.TranslateFieldTexts(context.Background(), "name", []string{"🔥ガンダム✨"})
+			if err != nil {
+				t.Fatalf("TranslateFieldTexts: %v", err)
+			}
+			want := strings.NewReplacer("⟦0⟧", "🔥", "⟦1⟧", "✨").Replace(tt.want)
+			if got[0] != want {
+				t.Errorf("translation = %q, want %q", got[0], want)
+			}
+			if sent := api.calls[0][0]; strings.ContainsAny(sent, "🔥✨") {
+				t.Errorf("sent %q, want the emoji masked", sent)
+			}
+		})
+	}
+}
+
+func TestMeasurementsKeptVerbatim(t *testing.T) {
+	dt, api := newFakeAPI(t, func(call int, texts []string) (int, string, string) {
+		// A model localizing the text but not the placeholder
+		return http.StatusOK, "1. " + strings.Replace(texts[0], "全高約", "全高约", 1), "stop"
+	})
+	masker, err := newSymbolMasker(false, true, nil)
+	if err != nil {
+		t.Fatalf("newSymbolMasker: %v", err)
+	}
+	dt.symbols = masker
+
+	got, err := dt.TranslateFieldTexts(context.Background(), "description", []string{"全高約180mm"})
+	if err != nil {
+		t.Fatalf("TranslateFieldTexts: %v", err)
+	}
+	if got[0] != "全高约180mm" {
+		t.Errorf("translation = %q, want 全高约180mm", got[0])
+	}
+	if sent := api.calls[0][0]; strings.Contains(sent, "180") {
+		t.Errorf("sent %q, want the measurement masked", sent)
+	}
+}
+
+func TestMeasurementPattern(t *testing.T) {
+	masker, err := newSymbolMasker(false, true, nil)
+	if err != nil {
+		t.Fatalf("newSymbolMasker: %v", err)
+	}
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"全高約180mm", []string{"180mm"}},
+		{"重さ1.5 kgです", []string{"1.5 kg"}},
+		{"10×20cmの箱", []string{"10×20cm"}},
+		{"1/7スケール", []string{"1/7"}},
+		{"50%", []string{"50%"}},
+		{"50% off", []string{"50%"}},
+		{"(最大50%)", []string{"50%"}},
+		{"5mmx", nil},
+		{"3個入り", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			_, symbols := masker.mask([]string{tt.text})
+			if !reflect.DeepEqual(symbols[0], tt.want) {
+				t.Errorf("masked %q from %q, want %q", symbols[0], tt.text, tt.want)
+			}
+		})
+	}
+}
+
+func TestSymbolMaskerRoundTrip(t *testing.T) {
+	masker, err := newSymbolMasker(true, true, []string{`[\x{2460}-\x{2473}]`})
+	if err != nil {
+		t.Fatalf("newSymbolMasker: %v", err)
+	}
+	texts := []string{"🔥全高約180mm①", "割引50% off", "記号なし"}
+	sent, symbols := masker.mask(texts)
+	if sent[2] != texts[2] || symbols[2] != nil {
+		t.Errorf("text without symbols masked to %q, %q", sent[2], symbols[2])
+	}
+	masker.restore(sent, symbols)
+	if !reflect.DeepEqual(sent, texts) {
+		t.Errorf("round trip = %q, want %q", sent, texts)
+	}
+	if _, err := newSymbolMasker(false, false, []string{"("}); err == nil {
+		t.Error("newSymbolMasker should reject an invalid pattern")
+	}
+}
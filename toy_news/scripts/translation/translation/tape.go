@@ -0,0 +1,113 @@
+package translation
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// tapeEntry is one recorded API exchange, a line of the tape file
+type tapeEntry struct {
+	RequestHash string          `json:"request_hash"`
+	Request     json.RawMessage `json:"request"`
+	StatusCode  int             `json:"status_code"`
+	Response    string          `json:"response"`
+}
+
+// apiTape records the API exchanges of a run to a JSONL file, or replays the
+// recorded responses instead of calling the API. Requests are matched by the
+// hash of their JSON body, so a replay needs the same prompts, model and
+// settings as the recording.
+type apiTape struct {
+	mu        sync.Mutex
+	file      *os.File             // recording, nil when replaying
+	responses map[string]tapeEntry // replaying, by request hash
+}
+
+// requestHash identifies a request body on the tape
+func requestHash(body []byte) string {
+	hash := sha256.Sum256(body)
+	return hex.EncodeToString(hash[:])
+}
+
+// recordTape opens path for recording, appending to an existing tape
+func recordTape(path string) (*apiTape, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open record file: %w", err)
+	}
+	return &apiTape{file: file}, nil
+}
+
+// replayTape loads the exchanges recorded at path. A request recorded more
+// than once replays its last response.
+func replayTape(path string) (*apiTape, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replay file: %w", err)
+	}
+	defer file.Close()
+
+	tape := &apiTape{responses: make(map[string]tapeEntry)}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry tapeEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("invalid replay entry on line %d: %w", line, err)
+		}
+		tape.responses[entry.RequestHash] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read replay file: %w", err)
+	}
+	return tape, nil
+}
+
+// replaying reports whether responses come from the tape instead of the API
+func (t *apiTape) replaying() bool {
+	return t.file == nil
+}
+
+// lookup returns the recorded response to a request body
+func (t *apiTape) lookup(body []byte) (int, []byte, error) {
+	hash := requestHash(body)
+	entry, ok := t.responses[hash]
+	if !ok {
+		return 0, nil, fmt.Errorf("no recorded response for request %s", hash)
+	}
+	return entry.StatusCode, []byte(entry.Response), nil
+}
+
+// record appends an exchange to the tape
+func (t *apiTape) record(body []byte, statusCode int, response []byte) error {
+	line, err := json.Marshal(tapeEntry{
+		RequestHash: requestHash(body),
+		Request:     body,
+		StatusCode:  statusCode,
+		Response:    string(response),
+	})
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, err = t.file.Write(append(line, '\n'))
+	return err
+}
+
+// Close closes the record file
+func (t *apiTape) Close() error {
+	if t.file == nil {
+		return nil
+	}
+	return t.file.Close()
+}
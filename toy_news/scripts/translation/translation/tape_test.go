@@ -0,0 +1,66 @@
+package translation
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTapeRecordReplay(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "tape.jsonl")
+	dt, api := newFakeAPI(t, nil)
+
+	recording, err := recordTape(path)
+	if err != nil {
+		t.Fatalf("recordTape: %v", err)
+	}
+	dt.tape = recording
+	batches := [][]string{{"赤", "青"}, {"ガンダム"}}
+	var recorded [][]string
+	for _, texts := range batches {
+		translations, err := dt.TranslateFieldTexts(ctx, "name", texts)
+		if err != nil {
+			t.Fatalf("recording %v: %v", texts, err)
+		}
+		recorded = append(recorded, translations)
+	}
+	if err := recording.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	dt.tape, err = replayTape(path)
+	if err != nil {
+		t.Fatalf("replayTape: %v", err)
+	}
+	calls := api.callCount()
+	// Replayed in the other order: requests are matched by hash, not position
+	for i := len(batches) - 1; i >= 0; i-- {
+		translations, err := dt.TranslateFieldTexts(ctx, "name", batches[i])
+		if err != nil {
+			t.Fatalf("replaying %v: %v", batches[i], err)
+		}
+		if !reflect.DeepEqual(translations, recorded[i]) {
+			t.Errorf("replayed %v, recorded %v", translations, recorded[i])
+		}
+	}
+	if api.callCount() != calls {
+		t.Errorf("replay called the API %d times", api.callCount()-calls)
+	}
+
+	// A request that wasn't recorded can't be answered
+	if _, err := dt.TranslateFieldTexts(ctx, "name", []string{"黄"}); err == nil {
+		t.Error("replaying an unrecorded request should fail")
+	}
+}
+
+func TestRequestHash(t *testing.T) {
+	a := requestHash([]byte(`{"model":"m","messages":[]}`))
+	if a != requestHash([]byte(`{"model":"m","messages":[]}`)) {
+		t.Error("requestHash isn't deterministic")
+	}
+	if a == requestHash([]byte(`{"model":"n","messages":[]}`)) {
+		t.Error("different requests share a hash")
+	}
+}
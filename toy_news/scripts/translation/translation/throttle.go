@@ -0,0 +1,102 @@
+package translation
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// throttleWindow is how many recent API calls the error rate is computed over
+const throttleWindow = 20
+
+// throttleErrorRate is the rolling error rate above which a failed call
+// halves the request rate; isolated failures leave it alone
+const throttleErrorRate = 0.1
+
+// adaptiveThrottle spaces API calls to a request rate that adapts to the
+// observed error rate, AIMD style: every successful call raises the rate by a
+// tenth of the maximum, a failure while the rolling error rate is high halves
+// it
+type adaptiveThrottle struct {
+	mu       sync.Mutex
+	rate     float64 // requests per second currently allowed
+	maxRate  float64
+	minRate  float64
+	next     time.Time // earliest start of the next call
+	outcomes []bool    // ring of recent outcomes, true for failures
+	pos      int
+}
+
+// newAdaptiveThrottle creates a throttle starting at maxRate requests per second
+func newAdaptiveThrottle(maxRate float64) *adaptiveThrottle {
+	return &adaptiveThrottle{rate: maxRate, maxRate: maxRate, minRate: maxRate / 64}
+}
+
+// wait blocks until the current rate allows another call
+func (t *adaptiveThrottle) wait(ctx context.Context) error {
+	t.mu.Lock()
+	now := time.Now()
+	start := t.next
+	if start.Before(now) {
+		start = now
+	}
+	t.next = start.Add(time.Duration(float64(time.Second) / t.rate))
+	t.mu.Unlock()
+
+	select {
+	case <-time.After(time.Until(start)):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// record adjusts the rate after a call
+func (t *adaptiveThrottle) record(failed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.outcomes) < throttleWindow {
+		t.outcomes = append(t.outcomes, failed)
+	} else {
+		t.outcomes[t.pos] = failed
+		t.pos = (t.pos + 1) % throttleWindow
+	}
+
+	if !failed {
+		t.rate += t.maxRate / 10
+		if t.rate > t.maxRate {
+			t.rate = t.maxRate
+		}
+		return
+	}
+	if t.errorRate() > throttleErrorRate && t.rate > t.minRate {
+		t.rate /= 2
+		if t.rate < t.minRate {
+			t.rate = t.minRate
+		}
+		log.Printf("🐢 API错误率 %.0f%%，请求速率降至 %.2f/s", t.errorRate()*100, t.rate)
+	}
+}
+
+// errorRate returns the share of failures among the recent calls
+func (t *adaptiveThrottle) errorRate() float64 {
+	if len(t.outcomes) == 0 {
+		return 0
+	}
+	failures := 0
+	for _, failed := range t.outcomes {
+		if failed {
+			failures++
+		}
+	}
+	return float64(failures) / float64(len(t.outcomes))
+}
+
+// currentRate returns the requests per second currently allowed
+func (t *adaptiveThrottle) currentRate() float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.rate
+}
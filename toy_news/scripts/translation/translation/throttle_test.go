@@ -0,0 +1,35 @@
+package translation
+
+import "testing"
+
+func TestAdaptiveThrottleRate(t *testing.T) {
+	throttle := newAdaptiveThrottle(10)
+	for i := 0; i < 10; i++ {
+		throttle.record(false)
+	}
+	if got := throttle.currentRate(); got != 10 {
+		t.Fatalf("rate after successes = %v, want the maximum 10", got)
+	}
+
+	// An isolated failure in a healthy window leaves the rate alone
+	throttle.record(true)
+	if got := throttle.currentRate(); got != 10 {
+		t.Errorf("rate after one failure = %v, want 10", got)
+	}
+
+	for i := 0; i < 5; i++ {
+		throttle.record(true)
+	}
+	burst := throttle.currentRate()
+	if burst >= 10 {
+		t.Fatalf("rate after a burst of errors = %v, want it lowered", burst)
+	}
+	if burst < 10.0/64 {
+		t.Errorf("rate = %v, want at least the minimum %v", burst, 10.0/64)
+	}
+
+	throttle.record(false)
+	if got := throttle.currentRate(); got <= burst {
+		t.Errorf("rate after recovering = %v, want it above %v", got, burst)
+	}
+}
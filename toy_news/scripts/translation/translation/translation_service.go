@@ -0,0 +1,2430 @@
+// Package translation translates Japanese product texts to Chinese with the
+// DeepSeek API, caching translations in MongoDB. TranslationService.Translate
+// serves other Go programs; Main runs the queue-processing service.
+package translation
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// minCheckInterval is the shortest check interval in seconds; a zero interval
+// would poll MongoDB in a busy loop
+const minCheckInterval = 1
+
+// Collections a pipeline reads its queue from and caches into unless it names
+// its own
+const (
+	defaultPendingCollection = "toys_translation_pending"
+	defaultCacheCollection   = "toys_translation_cache"
+)
+
+// TranslationService represents the main translation service
+type TranslationService struct {
+	mongoURI              string
+	mongoDB               string
+	mongoCollection       string
+	checkInterval         int
+	pipeline              string // name of the -pipelines entry served, "" for a single service
+	pendingCollectionName string
+	cacheCollectionName   string
+	translator            Translator
+	batchSize             int
+	running               bool
+	fieldsToTranslate     []string
+	bulkOrdered           bool
+	pendingDisposition    string
+	idleExitAfter         time.Duration
+	memoryCache           *lruCache     // optional in-process layer in front of cacheCollection
+	skipExisting          bool          // only translate fields without a stored translation
+	drain                 bool          // RunOnce streams the whole queue instead of one batch
+	maxBatchWait          time.Duration // Drain flushes a partial chunk once it has waited this long (0 waits for batchSize)
+	paused                bool          // toggled by pauseSignal, cleared by resumeSignal
+	pauseFile             string        // processing is paused while this file exists
+	strictProvenance      bool          // cache entries from another provider/model/prompt are misses
+	refusalPatterns       []*regexp.Regexp
+	outputTransforms      []outputTransform          // applied to translations before they are cached
+	stripRules            []stripRule                // boilerplate removed from source texts before translating
+	restoreStripped       bool                       // put stripped boilerplate back around the translations
+	cacheStats            fieldCacheStats            // cache hits and misses per field since startup
+	includeHashes         []string                   // only these products are processed when set
+	excludeHashes         []string                   // these products are never processed
+	statsTimeout          time.Duration              // bounds each stats query, 0 waits indefinitely
+	statsRetries          int                        // extra attempts for a failed stats query
+	mongoRetries          int                        // extra attempts for a failed connection or bulk write
+	writeSlots            writeSemaphore             // bounds the concurrent bulk writes (nil doesn't)
+	readOnly              bool                       // translations are not cached, for debugging
+	cacheReadOnly         atomic.Bool                // set once the cache refused a write for lack of permission
+	cacheWriteGrace       time.Duration              // a started cache write may outlive a cancelled run by this long (0 cancels it with the run)
+	cacheMaxEntryBytes    int                        // larger cache entries are skipped with a warning, the translation is still written (0 disables)
+	reportFile            *reportFile                // cycle reports are appended here (nil disables)
+	reportCollectionName  string                     // cycle reports are inserted here ("" disables)
+	metrics               *serviceMetrics            // cycle counts exported over OTLP (nil disables)
+	batches               atomic.Int64               // batches processed, numbering their batch IDs
+	outputEscape          string                     // escaping of the stored translations: none, html or json
+	overwrite             string                     // whether translations replace existing target values
+	timestampSource       string                     // server or client clock for updatedAt
+	statsFull             bool                       // cycle-end stats include the normalized collection counts
+	sampleRate            float64                    // fraction of fetched items translated per run, 1 translates all
+	sampler               *rand.Rand                 // seedable source for sampling
+	statusField           string                     // normalized field set to "full" or "partial", empty disables
+	noCacheFields         map[string]bool            // fields always translated by the API and never cached
+	fieldConfigs          map[string]FieldConfig     // WithFieldConfig settings, applied to the translator
+	cacheKey              CacheKeyFunc               // replaces the MD5 text hash when set
+	assembler             BatchAssembler             // groups texts into translator calls when set
+	minSourceChars        int                        // shorter source texts are copied verbatim instead of translated, 0 disables
+	sentenceCache         bool                       // multi-sentence texts are translated and cached per sentence
+	verifyWrites          bool                       // written translations are read back before their items leave the queue
+	review                reviewThresholds           // translations over a threshold go to review instead of live
+	fieldTargets          map[string]string          // where each field with its own target is written, see fieldpaths.go
+	targetSuffix          string                     // appended to the other fields for their target, "CN" unless a pipeline sets it
+	maxConsecutiveErrors  int                        // Run exits with an error after this many failed API calls in a row (0 disables)
+	maxAttempts           int                        // pending items failing this many times are dead-lettered (0 keeps them)
+	failureBackoff        time.Duration              // wait before retrying a failed item, doubling per failure (0 retries every cycle)
+	cacheMaxEntries       int64                      // the cache is evicted down to this many entries (0 disables)
+	queryFilter           bson.M                     // operator query the processed pending items must match
+	identityFields        map[string]bool            // fields whose translation may equal the source
+	fieldPredicate        FieldPredicate             // decides per item which fields need translating, nil translates all
+	cacheEvictInterval    time.Duration              // how often the cache is evicted
+	writeConcern          *writeconcern.WriteConcern // nil keeps the driver default
+	readConcern           *readconcern.ReadConcern   // nil keeps the driver default
+
+	// MongoDB collections
+	client               *mongo.Client
+	db                   *mongo.Database
+	normalizedCollection *mongo.Collection
+	pendingCollection    *mongo.Collection
+	processedCollection  *mongo.Collection
+	deadLetterCollection *mongo.Collection
+	reviewCollection     *mongo.Collection
+	reportCollection     *mongo.Collection
+	cacheCollection      *mongo.Collection
+}
+
+// PendingItem represents a pending translation item
+type PendingItem struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	ProductHash string             `bson:"product_hash"`
+	Name        string             `bson:"name,omitempty"`
+	Description string             `bson:"description,omitempty"`
+	CreatedAt   time.Time          `bson:"createdAt"`
+	Priority    int                `bson:"priority,omitempty"` // higher is translated first
+
+	// Extra holds the other fields of the pending document, such as the
+	// localized maps read by map fields
+	Extra bson.M `bson:",inline"`
+
+	skip map[string]bool // fields the field predicate ruled out
+}
+
+// pendingSort orders the pending queue: highest priority first, then oldest
+// first. Items without a priority count as priority 0.
+var pendingSort = bson.D{{Key: "priority", Value: -1}, {Key: "createdAt", Value: 1}}
+
+// TranslatedItem represents an item with translations
+type TranslatedItem struct {
+	PendingItem
+	NameCN        string `bson:"nameCN,omitempty"`
+	DescriptionCN string `bson:"descriptionCN,omitempty"`
+
+	fieldErrors  map[string]string // why a field got no translation
+	uncounted    map[string]bool   // fields whose failure doesn't count as an attempt
+	translations map[string]string // of fields other than name and description
+}
+
+// fieldValues returns the source text and translation of a field. Fields
+// other than name and description are read from Extra by their path.
+func (item *TranslatedItem) fieldValues(field string) (string, string) {
+	if item.skip[field] {
+		return "", ""
+	}
+	switch field {
+	case "name":
+		return item.Name, item.NameCN
+	case "description":
+		return item.Description, item.DescriptionCN
+	}
+	return lookupPath(item.Extra, field), item.translations[field]
+}
+
+// setTranslation sets the translation of a field
+func (item *TranslatedItem) setTranslation(field, translation string) {
+	switch field {
+	case "name":
+		item.NameCN = translation
+	case "description":
+		item.DescriptionCN = translation
+	default:
+		if item.translations == nil {
+			item.translations = make(map[string]string)
+		}
+		item.translations[field] = translation
+	}
+}
+
+// CacheItem represents a cached translation
+type CacheItem struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty"`
+	TextHash       string             `bson:"text_hash"`
+	OriginalText   string             `bson:"original_text"`
+	TranslatedText string             `bson:"translated_text"`
+	CreatedAt      time.Time          `bson:"created_at"`
+	UpdatedAt      time.Time          `bson:"updated_at"`
+	UsageCount     int                `bson:"usage_count"`
+
+	// Provenance of the translation, empty for entries cached before it was recorded
+	Provider      string `bson:"provider,omitempty"`
+	Model         string `bson:"model,omitempty"`
+	PromptVersion string `bson:"prompt_version,omitempty"`
+}
+
+// Provenance identifies what produced a translation
+type Provenance struct {
+	Provider      string
+	Model         string
+	PromptVersion string
+}
+
+// matches reports whether a cache entry was produced with this provenance
+func (p Provenance) matches(item CacheItem) bool {
+	return item.Provider == p.Provider && item.Model == p.Model && item.PromptVersion == p.PromptVersion
+}
+
+// UpdateOperation represents a bulk update operation
+type UpdateOperation struct {
+	ProductHash string
+	Updates     bson.M
+	Complete    bool // every field with source text got translated
+}
+
+// DeepSeekTranslator represents the DeepSeek API translator
+type DeepSeekTranslator struct {
+	provider    string // "deepseek", or "local" for an OpenAI-compatible local server
+	apiKey      string // optional for local servers
+	baseURL     string
+	model       string
+	temperature float64
+
+	// Retry settings for transient API failures
+	maxRetries     int
+	retryBaseDelay time.Duration
+	retryMaxDelay  time.Duration
+	retryJitter    string
+	rng            *rand.Rand
+	rngMu          sync.Mutex // rng is shared by concurrent sub-batches
+
+	// plainSingleText sends single-text batches without the numbered list
+	plainSingleText bool
+
+	// verbose logs the prompts and raw responses of every API call
+	verbose bool
+
+	// subBatchSize splits larger batches into API calls of at most this many
+	// texts (0 sends each batch in one call); concurrency bounds how many of
+	// those calls run at once
+	subBatchSize int
+	concurrency  int
+	ramp         *concurrencyRamp // slow start towards concurrency, nil starts at full concurrency
+
+	// fieldSubBatch and fieldConcurrency override subBatchSize and
+	// concurrency per field, so short reusable names and long descriptions
+	// can be tuned apart
+	fieldSubBatch    map[string]int
+	fieldConcurrency map[string]int
+
+	// throttle spaces API calls by the observed error rate (nil disables)
+	throttle *adaptiveThrottle
+
+	// symbols masks emoji and symbols during translation (nil disables)
+	symbols *symbolMasker
+
+	// tape records the API exchanges, or replays them instead of calling the API
+	tape *apiTape
+
+	// metrics records the API request durations (nil disables)
+	metrics *serviceMetrics
+
+	// limits caps the translation length per field
+	limits outputLimits
+
+	// batchTokens packs API calls up to this many estimated prompt tokens
+	// instead of a fixed number of texts (0 disables)
+	batchTokens int
+
+	// contextSize is the model's context window in tokens; batches estimated
+	// not to fit are split before sending (0 disables)
+	contextSize int
+
+	// listStart is the number the model gives the first item of its answer
+	listStart int
+
+	// newlineEscape replaces newlines inside texts of a numbered list and is
+	// turned back into newlines in the translations (empty disables)
+	newlineEscape string
+
+	prompts *promptSet
+
+	usage       usageTracker
+	latency     latencyWindow
+	errorStreak atomic.Int64 // consecutive failed API calls
+	calls       atomic.Int64 // API calls made, numbering them for the request IDs
+}
+
+// isRetryableAPIError reports whether a failed API call is worth retrying.
+// Rate limiting and server errors are transient, other HTTP statuses are not.
+// Calls that got no response (connection failures, timeouts) are retried, as
+// are responses without choices, which the API sends now and then under load.
+func isRetryableAPIError(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == 0 ||
+		errors.Is(apiErr.Err, ErrEmptyChoices) ||
+		apiErr.StatusCode == http.StatusTooManyRequests ||
+		apiErr.StatusCode >= 500
+}
+
+// ChatCompletionRequest represents the OpenAI-compatible chat completion request
+type ChatCompletionRequest struct {
+	Model       string    `json:"model"`
+	Temperature float64   `json:"temperature"`
+	MaxTokens   int       `json:"max_tokens,omitempty"`
+	Messages    []Message `json:"messages"`
+}
+
+// Message represents a chat message
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatCompletionResponse represents the API response
+type ChatCompletionResponse struct {
+	Choices []Choice      `json:"choices"`
+	Usage   Usage         `json:"usage"`
+	Error   *APIErrorBody `json:"error,omitempty"` // some local servers report errors with a 200
+}
+
+// Choice represents a response choice
+type Choice struct {
+	Message      Message `json:"message"`
+	Text         string  `json:"text,omitempty"` // completion-style servers answer with text
+	FinishReason string  `json:"finish_reason,omitempty"`
+}
+
+// content returns the text of a choice
+func (c Choice) content() string {
+	if c.Message.Content == "" {
+		return c.Text
+	}
+	return c.Message.Content
+}
+
+// reasoningRegex matches the reasoning block local reasoning models emit
+// before their answer
+var reasoningRegex = regexp.MustCompile(`(?s)<think>.*?</think>`)
+
+// NewDeepSeekTranslator creates a new DeepSeek translator
+func NewDeepSeekTranslator() *DeepSeekTranslator {
+	apiKey := os.Getenv("DEEPSEEK_API_KEY")
+	if apiKey == "" {
+		log.Fatal("DEEPSEEK_API_KEY environment variable is required")
+	}
+
+	prompts, err := newPromptSet("", nil)
+	if err != nil {
+		log.Fatalf("Failed to load default prompts: %v", err)
+	}
+
+	return &DeepSeekTranslator{
+		provider:        "deepseek",
+		apiKey:          apiKey,
+		baseURL:         defaultDeepSeekBase,
+		model:           defaultDeepSeekModel,
+		temperature:     1.3,
+		maxRetries:      3,
+		retryBaseDelay:  time.Second,
+		retryMaxDelay:   30 * time.Second,
+		retryJitter:     JitterFull,
+		plainSingleText: true,
+		newlineEscape:   defaultNewlineEscape,
+		listStart:       1,
+		prompts:         prompts,
+		rng:             rand.New(rand.NewSource(time.Now().UnixNano())),
+		usage: usageTracker{
+			inputPrice:  0.27,
+			outputPrice: 1.10,
+		},
+	}
+}
+
+// NewLocalTranslator creates a translator for a local model server exposing
+// an OpenAI-compatible endpoint, such as Ollama or LM Studio. The API key is
+// read from LOCAL_API_KEY and may be empty.
+func NewLocalTranslator(baseURL, model string) *DeepSeekTranslator {
+	prompts, err := newPromptSet("", nil)
+	if err != nil {
+		log.Fatalf("Failed to load default prompts: %v", err)
+	}
+
+	return &DeepSeekTranslator{
+		provider:        "local",
+		apiKey:          os.Getenv("LOCAL_API_KEY"),
+		baseURL:         strings.TrimRight(baseURL, "/"),
+		model:           model,
+		temperature:     1.3,
+		maxRetries:      3,
+		retryBaseDelay:  time.Second,
+		retryMaxDelay:   30 * time.Second,
+		retryJitter:     JitterFull,
+		plainSingleText: true,
+		newlineEscape:   defaultNewlineEscape,
+		listStart:       1,
+		prompts:         prompts,
+		rng:             rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Provenance returns the provider, model and prompt version used for a field.
+// The prompt version is a short hash of the field's rendered instructions.
+func (dt *DeepSeekTranslator) Provenance(field string) Provenance {
+	instructions, err := dt.prompts.instructions(field)
+	if err != nil {
+		instructions = ""
+	}
+	hash := md5.Sum([]byte(instructions))
+	return Provenance{
+		Provider:      dt.provider,
+		Model:         dt.model,
+		PromptVersion: hex.EncodeToString(hash[:])[:12],
+	}
+}
+
+// UsageTracker returns the tracker of the API usage and spend
+func (dt *DeepSeekTranslator) UsageTracker() *usageTracker {
+	return &dt.usage
+}
+
+// LatencyWindow returns the durations of the recent API calls
+func (dt *DeepSeekTranslator) LatencyWindow() *latencyWindow {
+	return &dt.latency
+}
+
+// callAPI calls the DeepSeek API, retrying transient failures with
+// exponential backoff
+func (dt *DeepSeekTranslator) callAPI(ctx context.Context, req ChatCompletionRequest) (string, error) {
+	if dt.usage.exhausted() {
+		return "", ErrBudgetExceeded
+	}
+
+	if dt.verbose {
+		for _, message := range req.Messages {
+			log.Printf("🐛 [%s] %s", message.Role, message.Content)
+		}
+	}
+
+	policy := retryPolicy{
+		maxRetries: dt.maxRetries,
+		baseDelay:  dt.retryBaseDelay,
+		maxDelay:   dt.retryMaxDelay,
+		jitter:     dt.retryJitter,
+		rng:        dt.rng,
+		rngMu:      &dt.rngMu,
+		retryable:  isRetryableAPIError,
+		onRetry: func(attempt int, err error, delay time.Duration) {
+			log.Printf("⚠️ API call failed (attempt %d/%d): %v, retrying in %s", attempt+1, dt.maxRetries+1, err, delay)
+		},
+	}
+
+	batch, call, attempt := batchID(ctx), dt.calls.Add(1), 0
+	var content string
+	err := retry(ctx, policy, func() error {
+		if dt.throttle != nil {
+			if err := dt.throttle.wait(ctx); err != nil {
+				return err
+			}
+		}
+		attempt++
+		id := requestID(batch, call, attempt)
+		log.Printf("📡 API调用 batch=%s attempt=%d/%d request=%s", batch, attempt, dt.maxRetries+1, id)
+		var err error
+		content, err = dt.doRequest(ctx, req, id)
+		if dt.throttle != nil {
+			// Only throttling and server trouble should slow the calls down
+			dt.throttle.record(isRetryableAPIError(err))
+		}
+		if dt.verbose {
+			log.Printf("🐛 API原始响应 (err=%v):\n%s", err, content)
+		}
+		return err
+	})
+
+	// Only calls the API failed count, a response of any kind ends the streak
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		dt.errorStreak.Add(1)
+	} else if err == nil || errors.Is(err, ErrTruncated) {
+		dt.errorStreak.Store(0)
+	}
+	return content, err
+}
+
+// ConsecutiveErrors returns how many API calls in a row failed after their
+// retries
+func (dt *DeepSeekTranslator) ConsecutiveErrors() int64 {
+	return dt.errorStreak.Load()
+}
+
+// APICalls returns how many API calls were made, retries not counted
+func (dt *DeepSeekTranslator) APICalls() int64 {
+	return dt.calls.Load()
+}
+
+// ThrottleRate returns the requests per second the adaptive throttle
+// currently allows, 0 without a throttle
+func (dt *DeepSeekTranslator) ThrottleRate() float64 {
+	if dt.throttle == nil {
+		return 0
+	}
+	return dt.throttle.currentRate()
+}
+
+// doRequest makes a single HTTP request to DeepSeek API, tagged with id
+func (dt *DeepSeekTranslator) doRequest(ctx context.Context, req ChatCompletionRequest, id string) (string, error) {
+	// Marshal request to JSON
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	statusCode, body, err := dt.exchange(ctx, jsonData, id)
+	if err != nil {
+		return "", err
+	}
+
+	// Check status code
+	if statusCode != http.StatusOK {
+		return "", &APIError{StatusCode: statusCode, Body: string(body)}
+	}
+
+	// Parse JSON response
+	var response ChatCompletionResponse
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return "", &APIError{StatusCode: statusCode, Body: string(body), Err: fmt.Errorf("failed to unmarshal response: %w", err)}
+	}
+	dt.usage.add(response.Usage)
+
+	if response.Error != nil && response.Error.Message != "" {
+		return "", &APIError{StatusCode: statusCode, Body: string(body), Err: errors.New(response.Error.Message)}
+	}
+
+	// Extract content from response
+	if len(response.Choices) == 0 {
+		return "", &APIError{StatusCode: statusCode, Body: string(body), Err: ErrEmptyChoices}
+	}
+
+	content := reasoningRegex.ReplaceAllString(response.Choices[0].content(), "")
+	if response.Choices[0].FinishReason == "length" {
+		// The model ran out of output tokens, the end of the answer is missing
+		return strings.TrimSpace(content), ErrTruncated
+	}
+	return strings.TrimSpace(content), nil
+}
+
+// exchange sends a request body to the API with id as its X-Request-ID and
+// returns the status code and response body. With a tape the exchange is recorded, or replayed from it
+// without calling the API.
+func (dt *DeepSeekTranslator) exchange(ctx context.Context, jsonData []byte, id string) (int, []byte, error) {
+	if dt.tape != nil && dt.tape.replaying() {
+		return dt.tape.lookup(jsonData)
+	}
+
+	// Create HTTP request
+	url := dt.baseURL + "/chat/completions"
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	// Set headers
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("X-Request-ID", id)
+	if dt.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+dt.apiKey)
+	}
+
+	// Create HTTP client with timeout
+	client := &http.Client{
+		Timeout: 60 * time.Second,
+	}
+
+	// Make the request
+	start := time.Now()
+	defer func() {
+		elapsed := time.Since(start)
+		dt.latency.add(elapsed)
+		dt.metrics.recordAPICall(ctx, dt.provider, dt.model, elapsed)
+	}()
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return 0, nil, &APIError{Err: fmt.Errorf("failed to make HTTP request: %w", err)}
+	}
+	defer resp.Body.Close()
+
+	// Read response body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, &APIError{StatusCode: resp.StatusCode, Err: fmt.Errorf("failed to read response body: %w", err)}
+	}
+
+	if dt.tape != nil {
+		if err := dt.tape.record(jsonData, resp.StatusCode, body); err != nil {
+			log.Printf("⚠️ 无法记录API请求: %v", err)
+		}
+	}
+	return resp.StatusCode, body, nil
+}
+
+// TranslateTexts translates multiple texts in batch using the global prompt
+func (dt *DeepSeekTranslator) TranslateTexts(ctx context.Context, texts []string) ([]string, error) {
+	return dt.TranslateFieldTexts(ctx, "", texts)
+}
+
+// TranslateFieldTexts translates multiple texts of one field in batch, using
+// the field's system prompt when one is configured. The translations are
+// aligned 1:1 with texts, however the batch is split into API calls. A
+// *CountMismatchError is returned together with usable translations when the
+// API answered with the wrong number of items or part of a split batch
+// failed, listing the texts that got no translation; any other error means
+// nothing was translated.
+func (dt *DeepSeekTranslator) TranslateFieldTexts(ctx context.Context, field string, texts []string) ([]string, error) {
+	if len(texts) == 0 {
+		return []string{}, nil
+	}
+	if content := nonBlankIndices(texts); len(content) < len(texts) {
+		return dt.translateNonBlank(ctx, field, texts, content)
+	}
+
+	sent, symbols := dt.symbols.mask(texts)
+	var translations []string
+	var err error
+	if batches := dt.splitForAPI(field, sent); len(batches) > 1 {
+		translations, err = dt.translateSubBatches(ctx, field, sent, batches)
+	} else {
+		translations, err = dt.translateBatch(ctx, field, sent)
+	}
+	dt.symbols.restore(translations, symbols)
+	return translations, err
+}
+
+// nonBlankIndices returns the indices of the texts that aren't empty or only
+// whitespace
+func nonBlankIndices(texts []string) []int {
+	var content []int
+	for i, text := range texts {
+		if strings.TrimSpace(text) != "" {
+			content = append(content, i)
+		}
+	}
+	return content
+}
+
+// translateNonBlank translates only the texts at content and copies the blank
+// ones verbatim, so a badly scraped batch of whitespace costs no API call
+func (dt *DeepSeekTranslator) translateNonBlank(ctx context.Context, field string, texts []string, content []int) ([]string, error) {
+	translations := append([]string(nil), texts...)
+	if len(content) == 0 {
+		log.Printf("⏭️  %d 个文本均为空白，跳过API调用", len(texts))
+		return translations, nil
+	}
+
+	sent := make([]string, len(content))
+	for i, index := range content {
+		sent[i] = texts[index]
+	}
+	translated, err := dt.TranslateFieldTexts(ctx, field, sent)
+	if len(translated) == len(content) {
+		for i, index := range content {
+			translations[index] = translated[i]
+		}
+	}
+
+	var mismatch *CountMismatchError
+	if errors.As(err, &mismatch) {
+		// Point the missing items back at the full batch
+		missing := make([]int, len(mismatch.Missing))
+		for i, index := range mismatch.Missing {
+			missing[i] = content[index]
+		}
+		return translations, &CountMismatchError{Got: mismatch.Got, Want: mismatch.Want, Missing: missing, Err: mismatch.Err}
+	}
+	return translations, err
+}
+
+// translateBatch translates texts of one field in a single API call
+func (dt *DeepSeekTranslator) translateBatch(ctx context.Context, field string, texts []string) ([]string, error) {
+	if len(texts) == 1 && dt.plainSingleText {
+		return dt.translateSingleText(ctx, field, texts[0])
+	}
+
+	systemPrompt, err := dt.prompts.systemPrompt(field, numberedListProtocol)
+	if err != nil {
+		return texts, err
+	}
+
+	// Log original texts being sent to API
+	log.Printf("📋 发送给API的原始文本 (共%d条):", len(texts))
+	for i, text := range texts {
+		log.Printf("  %d. %s", i+1, text)
+	}
+
+	// Combine texts with numbering, keeping each text on one line
+	sent, escaped := escapeNewlines(texts, dt.newlineEscape)
+	var combinedParts []string
+	for i, text := range sent {
+		combinedParts = append(combinedParts, fmt.Sprintf("%d. %s", i+1, text))
+	}
+	combinedText := strings.Join(combinedParts, "\n---\n")
+
+	instruction := fmt.Sprintf("Translate the following texts from Japanese to %s, keeping the same numbering format", dt.prompts.target)
+	if len(escaped) > 0 {
+		instruction += fmt.Sprintf(" and every %s line break marker", dt.newlineEscape)
+	}
+
+	log.Printf("⏳ 正在调用DeepSeek API翻译 %d 个文本...", len(texts))
+
+	// Create request
+	req := ChatCompletionRequest{
+		Model:       dt.model,
+		Temperature: dt.temperature,
+		MaxTokens:   dt.limits.requestMaxTokens(field, len(texts)),
+		Messages: []Message{
+			{
+				Role:    "system",
+				Content: systemPrompt,
+			},
+			{
+				Role:    "user",
+				Content: fmt.Sprintf("%s:\n%s%s%s", instruction, combinedText, dt.limits.instruction(field), dt.symbols.instruction(texts)),
+			},
+		},
+	}
+
+	// Make API call
+	response, err := dt.callAPI(ctx, req)
+	if errors.Is(err, ErrTruncated) && len(texts) > 1 {
+		// Retry in halves rather than padding the translations that got cut off
+		log.Printf("✂️  API响应被截断，拆分 %d 个文本重试", len(texts))
+		return dt.translateSubBatches(ctx, field, texts, splitBatch(texts, (len(texts)+1)/2))
+	}
+	if err != nil {
+		log.Printf("Translation API error: %v", err)
+		return texts, err // Return original texts on error
+	}
+
+	// Parse response
+	translations, sequential := dt.parseTranslations(response, len(texts))
+	if !sequential && len(texts) > 1 {
+		// Misnumbered items may be shifted against the texts, ask again in halves
+		log.Printf("🔢 API响应编号不连续，拆分 %d 个文本重试", len(texts))
+		return dt.translateSubBatches(ctx, field, texts, splitBatch(texts, (len(texts)+1)/2))
+	}
+	restoreNewlines(translations, escaped, dt.newlineEscape)
+	dt.limits.flagOverlong(field, translations)
+
+	// Validate translation count
+	if len(translations) != len(texts) {
+		mismatch := &CountMismatchError{Got: len(translations), Want: len(texts)}
+		log.Printf("Warning: %v", mismatch)
+
+		// Fix mismatched counts
+		if len(translations) > len(texts) {
+			translations = translations[:len(texts)]
+		} else {
+			for len(translations) < len(texts) {
+				missingIndex := len(translations)
+				translations = append(translations, texts[missingIndex])
+				mismatch.Missing = append(mismatch.Missing, missingIndex)
+			}
+		}
+		return translations, mismatch
+	}
+
+	return translations, nil
+}
+
+// translateSingleText translates one text with a plain prompt and takes the
+// whole response as the translation, so a missing "1." prefix can't be missed
+func (dt *DeepSeekTranslator) translateSingleText(ctx context.Context, field, text string) ([]string, error) {
+	systemPrompt, err := dt.prompts.systemPrompt(field, singleTextProtocol)
+	if err != nil {
+		return []string{text}, err
+	}
+
+	log.Printf("📋 发送给API的原始文本: %s", text)
+	log.Printf("⏳ 正在调用DeepSeek API翻译单个文本...")
+
+	req := ChatCompletionRequest{
+		Model:       dt.model,
+		Temperature: dt.temperature,
+		MaxTokens:   dt.limits.requestMaxTokens(field, 1),
+		Messages: []Message{
+			{
+				Role:    "system",
+				Content: systemPrompt,
+			},
+			{
+				Role:    "user",
+				Content: fmt.Sprintf("Translate the following text from Japanese to %s:\n%s%s%s", dt.prompts.target, text, dt.limits.instruction(field), dt.symbols.instruction([]string{text})),
+			},
+		},
+	}
+
+	response, err := dt.callAPI(ctx, req)
+	if err != nil {
+		log.Printf("Translation API error: %v", err)
+		return []string{text}, err // Return original text on error
+	}
+
+	translation := strings.TrimSpace(response)
+	if translation == "" {
+		return []string{text}, &CountMismatchError{Got: 0, Want: 1, Missing: []int{0}}
+	}
+	dt.limits.flagOverlong(field, []string{translation})
+	return []string{translation}, nil
+}
+
+// numberedLineRegex matches an item of a numbered list: "1.", "1)" or "1:",
+// with the full-width forms some models answer Chinese in
+var numberedLineRegex = regexp.MustCompile(`^(\d+)\s*[.)）:：]\s*(.+)$`)
+
+// parseTranslations parses the API response into individual translations.
+// Only lines starting with "N.", "N)" or "N:" count, so a preamble, closing
+// remarks and commentary between the items are dropped. It also reports
+// whether the items were numbered listStart, listStart+1... for expectedCount
+// items without gaps or repeats; otherwise the translations can't be trusted
+// to line up with the texts.
+func (dt *DeepSeekTranslator) parseTranslations(response string, expectedCount int) ([]string, bool) {
+	var translations []string
+	lines := strings.Split(strings.TrimSpace(response), "\n")
+	sequential := true
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+
+		// Skip empty lines and separators
+		if line == "" || line == "---" {
+			continue
+		}
+
+		// Match numbered lines
+		matches := numberedLineRegex.FindStringSubmatch(line)
+		if len(matches) == 3 {
+			want := dt.listStart + len(translations)
+			if n, err := strconv.Atoi(matches[1]); err != nil || n != want || (expectedCount > 0 && n >= dt.listStart+expectedCount) {
+				log.Printf("Warning: Item numbered %s out of sequence, expected %d", matches[1], want)
+				sequential = false
+			}
+			translation := strings.TrimSpace(matches[2])
+			if translation != "" {
+				translations = append(translations, translation)
+			} else {
+				log.Printf("Warning: Empty translation for line: %s", line)
+			}
+		} else {
+			log.Printf("Warning: Skipping non-numbered line: %s", line)
+		}
+	}
+
+	return translations, sequential
+}
+
+// NewTranslationService creates a new translation service instance,
+// customized by opts
+func NewTranslationService(mongoURI, mongoDB, mongoCollection string, checkInterval int, translator Translator, opts ...Option) *TranslationService {
+	refusalPatterns, err := compileRefusalPatterns(nil)
+	if err != nil {
+		log.Fatalf("Failed to compile refusal patterns: %v", err)
+	}
+
+	ts := &TranslationService{
+		mongoURI:              mongoURI,
+		mongoDB:               mongoDB,
+		mongoCollection:       mongoCollection,
+		checkInterval:         checkInterval,
+		pendingCollectionName: defaultPendingCollection,
+		cacheCollectionName:   defaultCacheCollection,
+		translator:            translator,
+		batchSize:             20,
+		running:               true,
+		fieldsToTranslate:     []string{"name", "description"},
+		targetSuffix:          "CN",
+		bulkOrdered:           true,
+		sampleRate:            1,
+		outputEscape:          EscapeNone,
+		overwrite:             OverwriteAlways,
+		timestampSource:       TimestampServer,
+		statsTimeout:          10 * time.Second,
+		statsRetries:          1,
+		mongoRetries:          2,
+		pendingDisposition:    "delete",
+		cacheMaxEntryBytes:    maxMongoDocumentBytes,
+		refusalPatterns:       refusalPatterns,
+	}
+	for _, opt := range opts {
+		opt(ts)
+	}
+	ts.applyFieldConfigs()
+	return ts
+}
+
+// usage returns the API usage tracker of the translator, or nil when the
+// translator doesn't track spend
+func (ts *TranslationService) usage() *usageTracker {
+	if tracking, ok := ts.translator.(usageTracking); ok {
+		return tracking.UsageTracker()
+	}
+	return nil
+}
+
+// ConnectMongoDB establishes MongoDB connection
+func (ts *TranslationService) ConnectMongoDB(ctx context.Context) error {
+	clientOptions := options.Client().ApplyURI(ts.mongoURI)
+	client, err := ts.connectMongo(ctx, clientOptions)
+	if err != nil {
+		return fmt.Errorf("failed to connect to MongoDB: %w", err)
+	}
+
+	// The collections inherit the concerns of the database
+	dbOpts := options.Database()
+	if ts.writeConcern != nil {
+		dbOpts.SetWriteConcern(ts.writeConcern)
+	}
+	if ts.readConcern != nil {
+		dbOpts.SetReadConcern(ts.readConcern)
+	}
+
+	ts.client = client
+	ts.db = client.Database(ts.mongoDB, dbOpts)
+	ts.normalizedCollection = ts.db.Collection(ts.mongoCollection)
+	ts.pendingCollection = ts.db.Collection(ts.pendingCollectionName)
+	ts.processedCollection = ts.db.Collection("toys_translation_processed")
+	ts.deadLetterCollection = ts.db.Collection(deadLetterCollectionName)
+	ts.cacheCollection = ts.db.Collection(ts.cacheCollectionName)
+	ts.reviewCollection = ts.db.Collection(reviewCollectionName)
+	if ts.reportCollectionName != "" {
+		ts.reportCollection = ts.db.Collection(ts.reportCollectionName)
+	}
+
+	// Create indexes
+	err = ts.createIndexes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create indexes: %w", err)
+	}
+
+	log.Println("Connected to MongoDB successfully")
+	return nil
+}
+
+// createIndexes creates necessary MongoDB indexes
+func (ts *TranslationService) createIndexes(ctx context.Context) error {
+	// Create cache index
+	indexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "text_hash", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	err := createIndex(ctx, ts.cacheCollection, indexModel)
+	if err != nil {
+		return fmt.Errorf("failed to create cache index: %w", err)
+	}
+
+	// A unique product_hash keeps re-enqueued products from accumulating in
+	// the queue. Existing duplicates prevent the index from being built; warn
+	// instead of refusing to start so the backlog still drains.
+	pendingIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "product_hash", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	err = createIndex(ctx, ts.pendingCollection, pendingIndex)
+	if err != nil {
+		if !mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("failed to create pending index: %w", err)
+		}
+		log.Printf("⚠️  待翻译队列存在重复的 product_hash，跳过唯一索引创建: %v", err)
+	}
+
+	err = createIndex(ctx, ts.pendingCollection, mongo.IndexModel{Keys: pendingSort})
+	if err != nil {
+		return fmt.Errorf("failed to create pending order index: %w", err)
+	}
+
+	if ts.cacheMaxEntries > 0 {
+		err = createIndex(ctx, ts.cacheCollection, mongo.IndexModel{Keys: evictionSort})
+		if err != nil {
+			return fmt.Errorf("failed to create cache eviction index: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// createIndex builds an index and logs how long it took. The createIndexes
+// command only returns once the build is complete, also when it joins a build
+// another instance started, so processing never runs ahead of the indexes.
+func createIndex(ctx context.Context, collection *mongo.Collection, model mongo.IndexModel) error {
+	log.Printf("⏳ 正在创建索引 %s %v ...", collection.Name(), model.Keys)
+	start := time.Now()
+	name, err := collection.Indexes().CreateOne(ctx, model)
+	if err != nil {
+		return err
+	}
+	log.Printf("✅ 索引 %s.%s 已就绪 (%s)", collection.Name(), name, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// EnqueuePending adds a product to the pending collection. It upserts by
+// product_hash, so enqueueing the same product again refreshes its source
+// fields without creating a duplicate or losing its queue position. A
+// re-enqueue can raise the item's priority but never lowers it, and clears the
+// done mark and recorded failure, so a changed product is translated again.
+func (ts *TranslationService) EnqueuePending(ctx context.Context, item PendingItem) error {
+	if item.ProductHash == "" {
+		return errors.New("cannot enqueue an item without a product_hash")
+	}
+	createdAt := item.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	filter := bson.M{"product_hash": item.ProductHash}
+	update := bson.M{
+		"$setOnInsert": bson.M{
+			"product_hash": item.ProductHash,
+			"createdAt":    createdAt,
+		},
+	}
+	fields := bson.M{}
+	if item.Name != "" {
+		fields["name"] = item.Name
+	}
+	if item.Description != "" {
+		fields["description"] = item.Description
+	}
+	if len(fields) > 0 {
+		update["$set"] = fields
+	}
+	if item.Priority > 0 {
+		update["$max"] = bson.M{"priority": item.Priority}
+	}
+	update["$unset"] = bson.M{"status": "", "processedAt": "", "lastError": "", "lastAttemptAt": "", "attempts": "", "nextAttemptAt": ""}
+	_, err := ts.pendingCollection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil && mongo.IsDuplicateKeyError(err) {
+		// A concurrent enqueue inserted the same product first.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to enqueue %s: %w", item.ProductHash, err)
+	}
+	return nil
+}
+
+// CloseMongoDB closes the MongoDB connection
+func (ts *TranslationService) CloseMongoDB(ctx context.Context) error {
+	if ts.client != nil {
+		return ts.client.Disconnect(ctx)
+	}
+	return nil
+}
+
+// GetTextHash generates MD5 hash of text, or the key of WithCacheKeyFunc
+func (ts *TranslationService) GetTextHash(text string) string {
+	if ts.cacheKey != nil {
+		return ts.cacheKey(text)
+	}
+	hash := md5.Sum([]byte(text))
+	return hex.EncodeToString(hash[:])
+}
+
+// memoryCacheKey returns the in-memory cache key of a text hash. With strict
+// provenance the key includes the field's provenance, since the same text may
+// be served to fields with different prompts.
+func (ts *TranslationService) memoryCacheKey(field, textHash string) string {
+	if !ts.strictProvenance {
+		return textHash
+	}
+	p := ts.translator.Provenance(field)
+	return textHash + "|" + p.Provider + "|" + p.Model + "|" + p.PromptVersion
+}
+
+// GetCachedTranslation retrieves the translation of a field's text from cache.
+// Fields marked no-cache always miss.
+// With strict provenance, entries produced by a different provider, model or
+// prompt version count as misses so they get refreshed.
+func (ts *TranslationService) GetCachedTranslation(ctx context.Context, field, text string) (string, error) {
+	if ts.noCacheFields[field] {
+		return "", nil
+	}
+	textHash := ts.GetTextHash(text)
+	memKey := ts.memoryCacheKey(field, textHash)
+
+	if ts.memoryCache != nil {
+		if translation, ok := ts.memoryCache.Get(memKey); ok {
+			return translation, nil
+		}
+	}
+
+	var cached CacheItem
+	err := ts.cacheCollection.FindOne(ctx, bson.M{"text_hash": textHash}).Decode(&cached)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return "", nil // Not found
+		}
+		return "", &CacheError{Op: "read", TextHash: textHash, Err: err}
+	}
+
+	if ts.strictProvenance && !ts.translator.Provenance(field).matches(cached) {
+		log.Printf("  ♻️ 缓存来源已过期 (%s/%s/%s)，重新翻译", cached.Provider, cached.Model, cached.PromptVersion)
+		return "", nil
+	}
+
+	if ts.memoryCache != nil {
+		ts.memoryCache.Put(memKey, cached.TranslatedText)
+	}
+	return cached.TranslatedText, nil
+}
+
+// CacheTranslation stores the translation of a field's text in cache along
+// with its provenance
+func (ts *TranslationService) CacheTranslation(ctx context.Context, field, originalText, translatedText string) error {
+	if ts.readOnly || ts.noCacheFields[field] || ts.cacheReadOnly.Load() {
+		return nil
+	}
+	textHash := ts.GetTextHash(originalText)
+	if size := len(originalText) + len(translatedText) + cacheEntryOverhead; ts.cacheMaxEntryBytes > 0 && size > ts.cacheMaxEntryBytes {
+		log.Printf("⚠️ %s 的缓存条目约 %d 字节，超过上限 %d，不缓存 (%s)", field, size, ts.cacheMaxEntryBytes, textHash)
+		return nil
+	}
+	memKey := ts.memoryCacheKey(field, textHash)
+	provenance := ts.translator.Provenance(field)
+	now := time.Now()
+
+	// A forced shutdown mustn't cut off the cache entry of a translation
+	// that is about to be committed
+	ctx, done := ts.cacheWriteContext(ctx)
+	defer done()
+
+	// Try to update existing cache entry
+	filter := bson.M{"text_hash": textHash}
+	update := bson.M{
+		"$setOnInsert": bson.M{"created_at": now},
+		"$set": bson.M{
+			"text_hash":       textHash,
+			"original_text":   originalText,
+			"translated_text": translatedText,
+			"updated_at":      now,
+			"usage_count":     1,
+			"provider":        provenance.Provider,
+			"model":           provenance.Model,
+			"prompt_version":  provenance.PromptVersion,
+		},
+	}
+
+	// Drop the in-memory copy first so a failed write never leaves it stale
+	if ts.memoryCache != nil {
+		ts.memoryCache.Remove(memKey)
+	}
+
+	opts := options.Update().SetUpsert(true)
+	result, err := ts.cacheCollection.UpdateOne(ctx, filter, update, opts)
+	if err != nil {
+		return ts.cacheWriteError(textHash, err)
+	}
+
+	if ts.memoryCache != nil {
+		ts.memoryCache.Put(memKey, translatedText)
+	}
+
+	// If it was an update (not insert), increment usage count
+	if result.UpsertedID == nil {
+		incUpdate := bson.M{
+			"$inc": bson.M{"usage_count": 1},
+			"$set": bson.M{"updated_at": now},
+		}
+		_, err = ts.cacheCollection.UpdateOne(ctx, filter, incUpdate)
+		if err != nil {
+			return ts.cacheWriteError(textHash, err)
+		}
+	}
+
+	return nil
+}
+
+// cacheEntryOverhead estimates the bytes of a cache entry besides its texts:
+// field names, hash, timestamps and provenance
+const cacheEntryOverhead = 512
+
+// maxMongoDocumentBytes is the BSON document size limit of MongoDB
+const maxMongoDocumentBytes = 16 * 1024 * 1024
+
+// cacheWriteContext returns the context of a cache write: one that stays
+// alive for cacheWriteGrace after ctx is cancelled, so the write can finish
+// before the Mongo client disconnects
+func (ts *TranslationService) cacheWriteContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ts.cacheWriteGrace <= 0 {
+		return ctx, func() {}
+	}
+	writeCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(ctx, func() {
+		time.AfterFunc(ts.cacheWriteGrace, cancel)
+	})
+	return writeCtx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// cacheWriteError wraps a failed cache write. A write refused for lack of
+// permission switches the cache to read-only, logged once, instead of failing
+// every following write the same way; translation carries on uncached.
+func (ts *TranslationService) cacheWriteError(textHash string, err error) error {
+	if !isUnauthorized(err) {
+		return &CacheError{Op: "write", TextHash: textHash, Err: err}
+	}
+	if ts.cacheReadOnly.CompareAndSwap(false, true) {
+		log.Printf("⚠️ 没有写入翻译缓存的权限，切换为只读缓存: %v", err)
+	}
+	return nil
+}
+
+// Translate translates texts of a field, returning translations aligned 1:1
+// with texts. Cached translations are served from the cache and the rest is
+// translated in one batch and cached. Texts that couldn't be translated keep
+// their original text, with the error explaining why. The service must be
+// connected with ConnectMongoDB first.
+func (ts *TranslationService) Translate(ctx context.Context, field string, texts []string) ([]string, error) {
+	results, _, err := ts.translate(ctx, field, texts)
+	return results, err
+}
+
+// translate is Translate, also returning the indices of the texts that kept
+// their original text because they couldn't be translated
+func (ts *TranslationService) translate(ctx context.Context, field string, texts []string) ([]string, map[int]bool, error) {
+	return ts.translateParts(ctx, field, texts, false)
+}
+
+// translateParts is translate. The sentences of a split text are parts: the
+// boilerplate was already stripped from the text and its cache lookup
+// counted, so neither happens again per sentence.
+func (ts *TranslationService) translateParts(ctx context.Context, field string, texts []string, parts bool) ([]string, map[int]bool, error) {
+	results := make([]string, len(texts))
+	missed := make(map[string][]int) // text -> indices in texts
+	var toTranslate []string
+
+	affixes := make([]affix, len(texts))
+
+	for i, text := range texts {
+		results[i] = text
+		if text == "" {
+			continue
+		}
+		if !parts {
+			text, affixes[i] = ts.stripBoilerplate(field, text)
+		}
+		if ts.isTrivial(text) {
+			continue
+		}
+		cached, err := ts.GetCachedTranslation(ctx, field, text)
+		if err != nil {
+			log.Printf("Error getting cached translation: %v", err)
+		}
+		if !parts {
+			ts.cacheStats.record(field, cached != "")
+		}
+		if cached != "" {
+			results[i] = affixes[i].wrap(cached)
+			continue
+		}
+		if missed[text] == nil {
+			toTranslate = append(toTranslate, text)
+		}
+		missed[text] = append(missed[text], i)
+	}
+
+	// Every missed text is untranslated until its translation comes in
+	untranslated := make(map[int]bool)
+	for _, indices := range missed {
+		for _, index := range indices {
+			untranslated[index] = true
+		}
+	}
+	if len(toTranslate) == 0 {
+		return results, untranslated, nil
+	}
+
+	translations, err := ts.translateTexts(ctx, field, toTranslate)
+	if err != nil && !errors.Is(err, ErrCountMismatch) {
+		return results, untranslated, err
+	}
+
+	missing := untranslatedIndices(err)
+	for i, translation := range translations {
+		if i >= len(toTranslate) {
+			break
+		}
+		original := toTranslate[i]
+		if missing[i] {
+			continue
+		}
+		if ts.isRefusal(translation) {
+			log.Printf("  🚫 模型拒绝翻译 %s: %s -> %s", field, original, translation)
+			continue
+		}
+		translation = ts.normalizeOutput(translation)
+		if ts.isIdentity(field, original, translation) {
+			log.Printf("  ⚠️ %s 的译文与原文相同，不缓存: %s", field, original)
+			// A sentence such as a model number may stay as it is; only the
+			// whole text has to differ from its source
+			if !parts {
+				continue
+			}
+		} else if cacheErr := ts.CacheTranslation(ctx, field, original, translation); cacheErr != nil {
+			log.Printf("Error caching translation: %v", cacheErr)
+		}
+		for _, index := range missed[original] {
+			results[index] = affixes[index].wrap(translation)
+			delete(untranslated, index)
+		}
+	}
+	return results, untranslated, err
+}
+
+// isTrivial reports whether a source text is too short to be worth an API
+// call, such as "1", "-" or "A", and is used as its own translation
+func (ts *TranslationService) isTrivial(text string) bool {
+	return ts.minSourceChars > 0 && utf8.RuneCountInString(strings.TrimSpace(text)) < ts.minSourceChars
+}
+
+// countFieldValues returns how many field values a text map fans out to
+func countFieldValues(textMap map[string][]int) int {
+	count := 0
+	for _, indices := range textMap {
+		count += len(indices)
+	}
+	return count
+}
+
+// TranslateWithCache translates items using cache
+func (ts *TranslationService) TranslateWithCache(ctx context.Context, items []PendingItem) ([]TranslatedItem, error) {
+	// Convert to translated items
+	translatedItems := make([]TranslatedItem, len(items))
+	for i, item := range items {
+		translatedItems[i] = TranslatedItem{PendingItem: item}
+	}
+
+	translationMap := make(map[string]map[string][]int) // field -> text -> item_indices
+	affixes := make(map[string][]affix)                 // field -> stripped boilerplate per item
+	sentenceSplits := make(map[string][]sentenceSplit)  // field -> texts translated per sentence
+	cacheHits := 0
+	cacheMisses := 0
+
+	// Check cache for each item
+	for i := range translatedItems {
+		item := &translatedItems[i]
+
+		// Log the pending item details
+		log.Printf("📝 处理项目 %d - ProductHash: %s", i+1, item.ProductHash)
+
+		for _, field := range ts.fieldsToTranslate {
+			originalText, _ := item.fieldValues(field)
+
+			if originalText != "" {
+				log.Printf("  🔤 需要翻译的%s: %s", field, originalText)
+
+				if affixes[field] == nil {
+					affixes[field] = make([]affix, len(translatedItems))
+				}
+				originalText, affixes[field][i] = ts.stripBoilerplate(field, originalText)
+				if ts.isTrivial(originalText) {
+					log.Printf("  ⏭️ %s 过短，原样保留: %s", field, originalText)
+					item.setTranslation(field, affixes[field][i].wrap(originalText))
+					continue
+				}
+
+				cachedTranslation, err := ts.GetCachedTranslation(ctx, field, originalText)
+				if err != nil {
+					log.Printf("Error getting cached translation: %v", err)
+					continue
+				}
+
+				if cachedTranslation != "" {
+					// Cache hit - set translation directly
+					log.Printf("  ✅ 缓存命中 %s: %s", field, cachedTranslation)
+					item.setTranslation(field, affixes[field][i].wrap(cachedTranslation))
+					ts.cacheStats.record(field, true)
+					cacheHits++
+				} else {
+					// Cache miss - add to translation map
+					log.Printf("  ❌ 缓存未命中 %s，需要API翻译", field)
+					ts.cacheStats.record(field, false)
+					cacheMisses++
+					if ts.sentenceCache {
+						if sentences := splitSentences(originalText); len(sentences) > 1 {
+							sentenceSplits[field] = append(sentenceSplits[field], sentenceSplit{index: i, sentences: sentences})
+							continue
+						}
+					}
+					if translationMap[field] == nil {
+						translationMap[field] = make(map[string][]int)
+					}
+					if translationMap[field][originalText] == nil {
+						translationMap[field][originalText] = []int{}
+					}
+					translationMap[field][originalText] = append(
+						translationMap[field][originalText],
+						i,
+					)
+				}
+			}
+		}
+	}
+
+	log.Printf("Cache hits: %d, Cache misses: %d", cacheHits, cacheMisses)
+
+	// Identical source texts of different products are one translation unit
+	uniqueMisses := 0
+	for field, textMap := range translationMap {
+		uniqueMisses += len(textMap)
+		if values := countFieldValues(textMap); values > len(textMap) {
+			log.Printf("🔁 %s: %d 个相同原文合并为 %d 个翻译单元", field, values, len(textMap))
+		}
+	}
+	log.Printf("Unique texts to translate: %d (for %d cache misses)", uniqueMisses, cacheMisses)
+
+	for field, splits := range sentenceSplits {
+		ts.translateSentences(ctx, field, translatedItems, splits, affixes[field])
+	}
+
+	// Translate uncached texts
+	for field, textMap := range translationMap {
+		if len(textMap) == 0 {
+			continue
+		}
+
+		// Prepare texts for batch translation. Sorted, so the same batch
+		// always makes the same request and -replay finds it on the tape.
+		var textsToTranslate []string
+		for text := range textMap {
+			textsToTranslate = append(textsToTranslate, text)
+		}
+		sort.Strings(textsToTranslate)
+		textOrder := textsToTranslate
+
+		log.Printf("Translating %d unique %s texts...", len(textsToTranslate), field)
+
+		// 打印即将翻译的文本列表
+		log.Printf("🚀 准备批量翻译 %s 字段，共 %d 个文本:", field, len(textsToTranslate))
+		for i, text := range textsToTranslate {
+			log.Printf("  [%d] %s", i+1, text)
+		}
+		log.Printf("📤 发送到DeepSeek API...")
+
+		// Batch translate
+		translations, err := ts.translateTexts(ctx, field, textsToTranslate)
+		if err != nil && !errors.Is(err, ErrCountMismatch) {
+			log.Printf("Error translating texts: %v", err)
+			for _, itemIndices := range textMap {
+				for _, itemIndex := range itemIndices {
+					translatedItems[itemIndex].setFieldFailure(field, err)
+				}
+			}
+			continue
+		}
+
+		// Process translation results. Texts the API left out come back as
+		// their source text; never cache those as translations.
+		missing := untranslatedIndices(err)
+		for i, translation := range translations {
+			if i >= len(textOrder) {
+				break
+			}
+
+			originalText := textOrder[i]
+			if missing[i] {
+				log.Printf("  ⚠️ API未返回 %s 的译文，保留待翻译: %s", field, originalText)
+				for _, itemIndex := range textMap[originalText] {
+					translatedItems[itemIndex].setFieldFailure(field, missingError(err))
+				}
+				continue
+			}
+
+			// Refusals are failures: don't cache them and keep the items pending
+			if ts.isRefusal(translation) {
+				log.Printf("  🚫 模型拒绝翻译 %s: %s -> %s", field, originalText, translation)
+				for _, itemIndex := range textMap[originalText] {
+					translatedItems[itemIndex].setFieldError(field, "model refused: "+translation)
+				}
+				continue
+			}
+			translation = ts.normalizeOutput(translation)
+			if ts.isIdentity(field, originalText, translation) {
+				log.Printf("  ⚠️ %s 的译文与原文相同，不缓存: %s", field, originalText)
+				for _, itemIndex := range textMap[originalText] {
+					translatedItems[itemIndex].setFieldError(field, "translation equals source")
+				}
+				continue
+			}
+
+			// Cache the translation
+			err = ts.CacheTranslation(ctx, field, originalText, translation)
+			if err != nil {
+				log.Printf("Error caching translation: %v", err)
+			}
+
+			// Update items with translation
+			itemIndices := textMap[originalText]
+			for _, itemIndex := range itemIndices {
+				translatedItems[itemIndex].setTranslation(field, affixes[field][itemIndex].wrap(translation))
+			}
+		}
+
+		// Log translation results
+		log.Printf("=== TRANSLATION RESULTS for %s ===", field)
+		for i, translation := range translations {
+			if i < len(textOrder) {
+				log.Printf("Translation %d: %s -> %s", i+1, textOrder[i], translation)
+			}
+		}
+		log.Printf("=== END TRANSLATION RESULTS ===")
+
+		log.Printf("✅ %s字段翻译完成，结果对比:", field)
+		for i, translation := range translations {
+			if i < len(textOrder) {
+				log.Printf("  原文: %s", textOrder[i])
+				log.Printf("  译文: %s", translation)
+				log.Printf("  ---")
+			}
+		}
+	}
+
+	return translatedItems, nil
+}
+
+// ProcessPendingTranslations processes the translation queue
+func (ts *TranslationService) ProcessPendingTranslations(ctx context.Context) (int, error) {
+	// Check pending count
+	pendingCount, err := ts.pendingCollection.CountDocuments(ctx, ts.processingFilter())
+	if err != nil {
+		return 0, fmt.Errorf("error counting pending items: %w", err)
+	}
+
+	if pendingCount == 0 {
+		return 0, nil
+	}
+
+	log.Printf("Found %d pending items", pendingCount)
+
+	// Get batch of pending items
+	opts := options.Find().SetSort(pendingSort).SetLimit(int64(ts.batchSize))
+	cursor, err := ts.pendingCollection.Find(ctx, ts.processingFilter(), opts)
+	if err != nil {
+		return 0, fmt.Errorf("error finding pending items: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	// Decode one document at a time so a malformed one only skips itself
+	var pendingItems []PendingItem
+	var malformed []interface{}
+	for cursor.Next(ctx) {
+		if item, ok := decodePending(cursor, &malformed); ok {
+			pendingItems = append(pendingItems, item)
+		}
+	}
+	if err = cursor.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating pending items: %w", err)
+	}
+	ts.deadLetterMalformed(ctx, malformed)
+
+	return ts.processBatch(ctx, pendingItems)
+}
+
+// DrainPending streams the whole pending queue through a single cursor and
+// processes it in batchSize chunks, committing each chunk as it goes. Items
+// that fail stay pending without being picked up again in the same drain.
+// With maxBatchWait a chunk is also flushed once its first item has waited
+// that long, trading batch size for latency when the cursor delivers slowly.
+func (ts *TranslationService) DrainPending(ctx context.Context) (int, error) {
+	opts := options.Find().
+		SetSort(pendingSort).
+		SetBatchSize(int32(ts.batchSize))
+	cursor, err := ts.pendingCollection.Find(ctx, ts.processingFilter(), opts)
+	if err != nil {
+		return 0, fmt.Errorf("error finding pending items: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	total := 0
+	chunks := 0
+	var chunk []PendingItem
+	var malformed []interface{}
+	defer func() { ts.deadLetterMalformed(ctx, malformed) }()
+	var chunkStarted time.Time
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		chunks++
+		log.Printf("Draining chunk %d (%d items)", chunks, len(chunk))
+		processed, err := ts.processBatch(ctx, chunk)
+		total += processed
+		chunk = nil
+		return err
+	}
+
+	for cursor.Next(ctx) {
+		item, ok := decodePending(cursor, &malformed)
+		if !ok {
+			continue
+		}
+
+		if len(chunk) == 0 {
+			chunkStarted = time.Now()
+		}
+		chunk = append(chunk, item)
+		waited := ts.maxBatchWait > 0 && time.Since(chunkStarted) >= ts.maxBatchWait
+		if len(chunk) >= ts.batchSize || waited {
+			err = flush()
+			if err != nil {
+				return total, err
+			}
+		}
+	}
+	if err = cursor.Err(); err != nil {
+		return total, fmt.Errorf("error iterating pending items: %w", err)
+	}
+
+	err = flush()
+	if err != nil {
+		return total, err
+	}
+
+	log.Printf("Drained %d items in %d chunks", total, chunks)
+	return total, nil
+}
+
+// processBatch translates a batch of pending items, writes the translations
+// to the normalized collection and takes the finished items out of the queue.
+// Each batch is one cycle of the cycle reports.
+func (ts *TranslationService) processBatch(ctx context.Context, pendingItems []PendingItem) (int, error) {
+	if len(pendingItems) == 0 {
+		return 0, nil
+	}
+
+	report := &CycleReport{
+		Batch:   fmt.Sprintf("b%d", ts.batches.Add(1)),
+		Started: time.Now(),
+		Items:   len(pendingItems),
+	}
+	ctx = withBatchID(ctx, report.Batch)
+	log.Printf("📦 批次 %s: %d 个项目", report.Batch, len(pendingItems))
+
+	cacheCounts := ts.cacheStats.snapshot()
+	calls := ts.apiCalls()
+	processed, err := ts.processItems(ctx, pendingItems, report)
+	if ts.reportsEnabled() || ts.metrics != nil {
+		report.DurationMs = time.Since(report.Started).Milliseconds()
+		report.Processed = processed
+		report.FieldCache = cacheDelta(cacheCounts, ts.cacheStats.snapshot())
+		for _, counts := range report.FieldCache {
+			report.CacheHits += counts.Hits
+			report.CacheMisses += counts.Misses
+		}
+		report.APICalls = ts.apiCalls() - calls
+		report.ThrottleRate = ts.throttleRate()
+		if err != nil {
+			report.Error = err.Error()
+		}
+		ts.metrics.recordCycle(ctx, ts.pipeline, report)
+		ts.writeCycleReport(ctx, report)
+	}
+	return processed, err
+}
+
+// processItems does the work of processBatch, noting the products updated
+// and the failures in report
+func (ts *TranslationService) processItems(ctx context.Context, pendingItems []PendingItem, report *CycleReport) (int, error) {
+	var err error
+
+	// Translations are written by product_hash, so items without one can never
+	// be stored
+	pendingItems, err = ts.dropHashlessItems(ctx, pendingItems)
+	if err != nil {
+		return 0, err
+	}
+	if len(pendingItems) == 0 {
+		return 0, nil
+	}
+
+	// Canary runs only translate a random sample, the rest stays pending
+	if ts.sampleRate < 1 {
+		pendingItems = ts.sampleItems(pendingItems)
+		if len(pendingItems) == 0 {
+			return 0, nil
+		}
+	}
+
+	// Leave fields that already have a stored translation untouched
+	var alreadyTranslated []string
+	if ts.skipExisting {
+		pendingItems, alreadyTranslated, err = ts.skipExistingFields(ctx, pendingItems)
+		if err != nil {
+			return 0, fmt.Errorf("error checking existing translations: %w", err)
+		}
+	}
+
+	// Fields the predicate rules out count as having nothing to translate
+	var notNeeded []string
+	pendingItems, notNeeded = ts.applyFieldPredicate(pendingItems)
+	alreadyTranslated = append(alreadyTranslated, notNeeded...)
+
+	log.Printf("Processing %d items with cache...", len(pendingItems))
+
+	// Translate with cache
+	translatedItems, err := ts.TranslateWithCache(ctx, pendingItems)
+	if err != nil {
+		return 0, fmt.Errorf("error translating items: %w", err)
+	}
+
+	// Prepare bulk operations
+	var updateOps []UpdateOperation
+	var reviewOps []UpdateOperation
+	reviewReasons := make(map[string][]string) // product hash -> why it needs review
+	failures := make(map[string]string)        // product hash -> why it stays pending
+	uncounted := make(map[string]bool)         // product hashes whose failure isn't an attempt
+
+	for i := range translatedItems {
+		item := &translatedItems[i]
+		updates := bson.M{}
+		complete := true
+		var untranslated []string
+
+		// Check for translations and prepare updates. Items missing a
+		// translation for any field stay pending to be retried.
+		for _, field := range ts.fieldsToTranslate {
+			source, translation := item.fieldValues(field)
+			if translation != "" && ts.isIdentity(field, source, translation) {
+				// Last line of defense, whichever path produced the translation
+				log.Printf("⚠️  %s 的 %s 译文与原文相同，保留待翻译", item.ProductHash, field)
+				item.setFieldError(field, "translation equals source")
+				translation = ""
+			}
+			if translation != "" {
+				updates[ts.targetKey(field)] = escapeOutput(translation, ts.outputEscape)
+			} else if source != "" {
+				complete = false
+				untranslated = append(untranslated, field)
+			}
+		}
+		if len(untranslated) > 0 {
+			failures[item.ProductHash] = item.failureReason(untranslated)
+			uncounted[item.ProductHash] = !item.failureCounts(untranslated)
+		}
+
+		if len(updates) > 0 {
+			if ts.statusField != "" {
+				updates[ts.statusField] = translationStatus(complete)
+			}
+			op := UpdateOperation{
+				ProductHash: item.ProductHash,
+				Updates:     updates,
+				Complete:    complete,
+			}
+			if ts.review.enabled() {
+				if reasons := ts.reviewReasons(item); len(reasons) > 0 {
+					reviewReasons[item.ProductHash] = reasons
+					reviewOps = append(reviewOps, op)
+					continue
+				}
+			}
+			updateOps = append(updateOps, op)
+		}
+	}
+
+	// Translations held for review leave the queue like stored ones
+	err = ts.sendToReview(ctx, reviewOps, reviewReasons)
+	if err != nil {
+		return 0, err
+	}
+	for _, op := range reviewOps {
+		if op.Complete {
+			alreadyTranslated = append(alreadyTranslated, op.ProductHash)
+		}
+	}
+
+	committed := updateOps
+
+	// Execute bulk operations
+	if len(updateOps) > 0 {
+		var bulkOps []mongo.WriteModel
+		for _, op := range updateOps {
+			filter := bson.M{"product_hash": op.ProductHash}
+			update := ts.normalizedUpdate(op.Updates)
+			bulkOps = append(bulkOps, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update))
+		}
+
+		bulkOpts := options.BulkWrite().SetOrdered(ts.bulkOrdered)
+		bulkResult, err := ts.bulkWrite(ctx, ts.normalizedCollection, bulkOps, bulkOpts)
+		if err != nil {
+			var bulkErr mongo.BulkWriteException
+			if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil || len(bulkErr.WriteErrors) == 0 {
+				return 0, fmt.Errorf("error executing bulk write: %w", err)
+			}
+
+			// Only the operations that were applied may leave the pending queue
+			for _, writeErr := range bulkErr.WriteErrors {
+				if writeErr.Index < len(updateOps) {
+					log.Printf("Error updating product %s: %v", updateOps[writeErr.Index].ProductHash, writeErr.WriteError)
+					failures[updateOps[writeErr.Index].ProductHash] = "write failed: " + writeErr.Message
+				}
+			}
+			committed = committedOperations(updateOps, bulkErr, ts.bulkOrdered)
+			log.Printf("Bulk write failed for %d of %d products, %d committed",
+				len(updateOps)-len(committed), len(updateOps), len(committed))
+		} else {
+			log.Printf("Updated %d products in %s", bulkResult.ModifiedCount, ts.mongoCollection)
+		}
+
+		// An update that matched nothing means the normalized product was
+		// deleted after being enqueued. Its translation can't be stored, so
+		// dead-letter the pending item instead of disposing of it as done.
+		if bulkResult == nil || bulkResult.MatchedCount < int64(len(committed)) {
+			committed, err = ts.dropMissingProducts(ctx, committed)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		if ts.verifyWrites {
+			committed, err = ts.verifyCommitted(ctx, committed)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	// Remove processed items from pending collection
+	var pendingDeletions []string
+	for _, op := range committed {
+		report.Updated = append(report.Updated, op.ProductHash)
+		if op.Complete {
+			pendingDeletions = append(pendingDeletions, op.ProductHash)
+		}
+	}
+	pendingDeletions = append(pendingDeletions, alreadyTranslated...)
+	if len(pendingDeletions) > 0 {
+		disposed, err := ts.disposePending(ctx, pendingDeletions)
+		if err != nil {
+			return 0, fmt.Errorf("error disposing pending items: %w", err)
+		}
+
+		log.Printf("Disposed %d items from translation_pending (%s)", disposed, ts.pendingDisposition)
+	}
+
+	// Failing to record why items failed must not fail the cycle
+	report.Failures = failures
+	err = ts.recordFailures(ctx, failures, uncounted)
+	if err != nil {
+		log.Printf("Error recording failures: %v", err)
+	}
+
+	return len(pendingDeletions), nil
+}
+
+// translationStatus returns the status recorded on a normalized product:
+// "full" once every field with source text is translated, "partial" otherwise
+func translationStatus(complete bool) string {
+	if complete {
+		return "full"
+	}
+	return "partial"
+}
+
+// sampleItems keeps each item with probability sampleRate
+func (ts *TranslationService) sampleItems(items []PendingItem) []PendingItem {
+	var sampled []PendingItem
+	for _, item := range items {
+		if ts.sampler.Float64() < ts.sampleRate {
+			sampled = append(sampled, item)
+		}
+	}
+	log.Printf("🎲 Sampled %d of %d items (rate %.2f)", len(sampled), len(items), ts.sampleRate)
+	return sampled
+}
+
+// skipExistingFields skips every field that already has a non-empty
+// translation in the normalized collection. Items left with nothing to
+// translate are returned separately by product hash as already done.
+func (ts *TranslationService) skipExistingFields(ctx context.Context, items []PendingItem) ([]PendingItem, []string, error) {
+	hashes := make([]string, len(items))
+	for i, item := range items {
+		hashes[i] = item.ProductHash
+	}
+
+	projection := bson.M{"product_hash": 1}
+	for _, field := range ts.fieldsToTranslate {
+		projection[ts.targetKey(field)] = 1
+	}
+	opts := options.Find().SetProjection(projection)
+	cursor, err := ts.normalizedCollection.Find(ctx, bson.M{"product_hash": bson.M{"$in": hashes}}, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []NormalizedItem
+	err = cursor.All(ctx, &docs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	existing := make(map[string]NormalizedItem, len(docs))
+	for _, doc := range docs {
+		existing[doc.ProductHash] = doc
+	}
+	remaining, done := ts.skipTranslatedFields(items, existing)
+	return remaining, done, nil
+}
+
+// skipTranslatedFields marks the fields of the items whose normalized product,
+// keyed by product hash, already has a translation as skipped. It returns the
+// items with fields left to translate and the product hashes of the others.
+func (ts *TranslationService) skipTranslatedFields(items []PendingItem, existing map[string]NormalizedItem) ([]PendingItem, []string) {
+	var remaining []PendingItem
+	var done []string
+	for _, item := range items {
+		doc := existing[item.ProductHash]
+		for _, field := range ts.fieldsToTranslate {
+			pending := TranslatedItem{PendingItem: item}
+			if source, _ := pending.fieldValues(field); source == "" {
+				continue
+			}
+			if _, translated := ts.itemField(doc, field); translated != "" {
+				log.Printf("  ⏭️ %s already has %s, skipping %s", item.ProductHash, ts.targetKey(field), field)
+				if item.skip == nil {
+					item.skip = make(map[string]bool)
+				}
+				item.skip[field] = true
+			}
+		}
+
+		if !ts.hasSourceText(item) {
+			done = append(done, item.ProductHash)
+			continue
+		}
+		remaining = append(remaining, item)
+	}
+	return remaining, done
+}
+
+// hasSourceText reports whether any field of the item still has text to translate
+func (ts *TranslationService) hasSourceText(item PendingItem) bool {
+	translated := TranslatedItem{PendingItem: item}
+	for _, field := range ts.fieldsToTranslate {
+		if source, _ := translated.fieldValues(field); source != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// pendingFilter returns the filter matching items still waiting for translation
+func (ts *TranslationService) pendingFilter() bson.M {
+	if ts.pendingDisposition == "mark" {
+		return bson.M{"status": bson.M{"$ne": "done"}}
+	}
+	return bson.M{}
+}
+
+// disposePending takes processed items out of the pending queue according to
+// the configured disposition: delete removes them, archive moves them to the
+// processed collection and mark flags them as done in place.
+func (ts *TranslationService) disposePending(ctx context.Context, productHashes []string) (int64, error) {
+	filter := bson.M{"product_hash": bson.M{"$in": productHashes}}
+	now := time.Now()
+
+	switch ts.pendingDisposition {
+	case "mark":
+		update := bson.M{"$set": bson.M{"status": "done", "processedAt": now}}
+		result, err := ts.updateMany(ctx, ts.pendingCollection, filter, update)
+		if err != nil {
+			return 0, err
+		}
+		return result.ModifiedCount, nil
+
+	case "archive":
+		cursor, err := ts.pendingCollection.Find(ctx, filter)
+		if err != nil {
+			return 0, err
+		}
+		var docs []bson.M
+		err = cursor.All(ctx, &docs)
+		if err != nil {
+			return 0, err
+		}
+
+		if len(docs) > 0 {
+			// Upserting by _id keeps a retried archive from failing on duplicates
+			var models []mongo.WriteModel
+			for _, doc := range docs {
+				doc["processedAt"] = now
+				models = append(models, mongo.NewReplaceOneModel().
+					SetFilter(bson.M{"_id": doc["_id"]}).
+					SetReplacement(doc).
+					SetUpsert(true))
+			}
+			_, err = ts.bulkWrite(ctx, ts.processedCollection, models)
+			if err != nil {
+				return 0, fmt.Errorf("failed to archive pending items: %w", err)
+			}
+		}
+		fallthrough
+
+	default:
+		result, err := ts.deleteMany(ctx, ts.pendingCollection, filter)
+		if err != nil {
+			return 0, err
+		}
+		return result.DeletedCount, nil
+	}
+}
+
+// dropMissingProducts dead-letters the pending items of ops whose normalized
+// product doesn't exist and returns the remaining operations
+func (ts *TranslationService) dropMissingProducts(ctx context.Context, ops []UpdateOperation) ([]UpdateOperation, error) {
+	if len(ops) == 0 {
+		return ops, nil
+	}
+	missing, err := ts.missingProducts(ctx, ops)
+	if err != nil {
+		return nil, fmt.Errorf("error checking updated products: %w", err)
+	}
+	if len(missing) == 0 {
+		return ops, nil
+	}
+
+	var kept []UpdateOperation
+	var hashes []string
+	for _, op := range ops {
+		if missing[op.ProductHash] {
+			log.Printf("⚠️  %s 在 %s 中不存在，翻译无法保存", op.ProductHash, ts.mongoCollection)
+			hashes = append(hashes, op.ProductHash)
+		} else {
+			kept = append(kept, op)
+		}
+	}
+	filter := bson.M{"product_hash": bson.M{"$in": hashes}}
+	_, err = ts.deadLetter(ctx, filter, "normalized product not found")
+	if err != nil {
+		return nil, err
+	}
+	return kept, nil
+}
+
+// committedOperations returns the update operations that were applied despite
+// a bulk write error. An ordered bulk write stops at the first failing
+// operation, while an unordered one only skips the failures.
+func committedOperations(ops []UpdateOperation, bulkErr mongo.BulkWriteException, ordered bool) []UpdateOperation {
+	failed := make(map[int]bool)
+	firstFailed := len(ops)
+	for _, writeErr := range bulkErr.WriteErrors {
+		failed[writeErr.Index] = true
+		if writeErr.Index < firstFailed {
+			firstFailed = writeErr.Index
+		}
+	}
+
+	var committed []UpdateOperation
+	for i, op := range ops {
+		if ordered && i >= firstFailed {
+			break
+		}
+		if !failed[i] {
+			committed = append(committed, op)
+		}
+	}
+	return committed
+}
+
+// showProductStats displays the translated and total product counts
+func (ts *TranslationService) showProductStats(ctx context.Context) {
+	// Translated products count, by the configured target of every field
+	var targetFilters []bson.M
+	for _, field := range ts.fieldsToTranslate {
+		targetFilters = append(targetFilters, bson.M{ts.targetKey(field): bson.M{"$exists": true}})
+	}
+	translatedFilter := bson.M{"$or": targetFilters}
+	translatedCount, translatedOK := ts.statsCount(ctx, "translated items", func(ctx context.Context) (int64, error) {
+		return ts.normalizedCollection.CountDocuments(ctx, translatedFilter)
+	})
+	totalProducts, totalOK := ts.statsCount(ctx, "total products", func(ctx context.Context) (int64, error) {
+		return ts.normalizedCollection.CountDocuments(ctx, bson.M{})
+	})
+
+	fmt.Printf("Translated products: %s/%s\n", formatCount(translatedCount, translatedOK), formatCount(totalProducts, totalOK))
+}
+
+// cacheUsage returns the summed usage count of the cache entries, or
+// fallback when the entries carry no usage counts
+func (ts *TranslationService) cacheUsage(ctx context.Context, fallback int64) (int64, error) {
+	pipeline := bson.A{
+		bson.M{
+			"$group": bson.M{
+				"_id":         nil,
+				"total_usage": bson.M{"$sum": "$usage_count"},
+			},
+		},
+	}
+
+	cursor, err := ts.cacheCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, fmt.Errorf("error aggregating cache usage: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var result []bson.M
+	err = cursor.All(ctx, &result)
+	if err != nil {
+		return 0, fmt.Errorf("error decoding cache usage: %w", err)
+	}
+
+	totalUsage := fallback
+	if len(result) > 0 && result[0]["total_usage"] != nil {
+		if usage, ok := toInt64(result[0]["total_usage"]); ok {
+			totalUsage = usage
+		} else {
+			log.Printf("Warning: unexpected cache usage type %T", result[0]["total_usage"])
+		}
+	}
+	return totalUsage, nil
+}
+
+// ShowStats displays service statistics. The translated and total product
+// counts scan the whole normalized collection, so they are only shown when
+// full is set. Each query is bounded by the stats timeout; counts that can't
+// be fetched are shown as n/a instead of holding up the report.
+func (ts *TranslationService) ShowStats(ctx context.Context, full bool) error {
+	// Pending translations count
+	pendingCount, ok := ts.statsCount(ctx, "pending items", func(ctx context.Context) (int64, error) {
+		return ts.pendingCollection.CountDocuments(ctx, ts.pendingFilter())
+	})
+	fmt.Printf("Translation pending: %s items\n", formatCount(pendingCount, ok))
+
+	if full {
+		ts.showProductStats(ctx)
+	}
+
+	// Cache statistics
+	totalCached, cachedOK := ts.statsCount(ctx, "cache items", func(ctx context.Context) (int64, error) {
+		return ts.cacheCollection.CountDocuments(ctx, bson.M{})
+	})
+
+	if !cachedOK || totalCached > 0 {
+		totalUsage, usageOK := int64(0), false
+		if cachedOK {
+			totalUsage, usageOK = ts.statsCount(ctx, "cache usage", func(ctx context.Context) (int64, error) {
+				return ts.cacheUsage(ctx, totalCached)
+			})
+		}
+		fmt.Printf("Translation cache: %s entries, %s total uses\n", formatCount(totalCached, cachedOK), formatCount(totalUsage, usageOK))
+	}
+
+	if ts.memoryCache != nil {
+		fmt.Printf("Memory cache: %d/%d entries\n", ts.memoryCache.Len(), ts.memoryCache.capacity)
+	}
+	ts.cacheStats.print()
+
+	if prompt, completion := ts.usage().tokens(); prompt+completion > 0 {
+		fmt.Printf("API usage: %d prompt + %d completion tokens, estimated cost $%.4f\n",
+			prompt, completion, ts.usage().cost())
+	}
+	if tracking, ok := ts.translator.(latencyTracking); ok {
+		tracking.LatencyWindow().print()
+	}
+
+	return nil
+}
+
+// tickInterval returns the time between cycles, raising a check interval
+// shorter than minCheckInterval to it
+func (ts *TranslationService) tickInterval() time.Duration {
+	if ts.checkInterval < minCheckInterval {
+		log.Printf("⚠️  Check interval %ds is too short, using %ds", ts.checkInterval, minCheckInterval)
+		ts.checkInterval = minCheckInterval
+	}
+	return time.Duration(ts.checkInterval) * time.Second
+}
+
+// Run starts the translation service
+func (ts *TranslationService) Run(ctx context.Context) error {
+	log.Println("Starting Unified Translation Service...")
+	if ts.pipeline != "" {
+		log.Printf("Pipeline: %s (%s)", ts.pipeline, ts.pendingCollectionName)
+	}
+	log.Printf("Processing translations for %s collection", ts.mongoCollection)
+	log.Printf("Check interval: %d seconds", ts.checkInterval)
+	log.Printf("Batch size: %d", ts.batchSize)
+	log.Printf("Fields to translate: %v", ts.fieldsToTranslate)
+	if ts.idleExitAfter > 0 {
+		log.Printf("Idle exit after: %s", ts.idleExitAfter)
+	}
+	log.Println()
+
+	// Connect to MongoDB
+	err := ts.ConnectMongoDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to MongoDB: %w", err)
+	}
+	defer ts.CloseMongoDB(ctx)
+
+	// Show initial stats
+	err = ts.ShowStats(ctx, true)
+	if err != nil {
+		log.Printf("Error showing initial stats: %v", err)
+	}
+	log.Println()
+
+	// Setup signal handling
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	ctlChan := make(chan os.Signal, 1)
+	if signals := controlSignals(); len(signals) > 0 {
+		signal.Notify(ctlChan, signals...)
+	}
+
+	ticker := time.NewTicker(ts.tickInterval())
+	defer ticker.Stop()
+
+	lastActive := time.Now()
+
+	// The first shutdown signal lets the in-flight batch finish, a second one
+	// cancels it
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if ts.cacheMaxEntries > 0 {
+		evictCtx, stopEviction := context.WithCancel(runCtx)
+		defer stopEviction()
+		go ts.runCacheEviction(evictCtx, ts.cacheEvictInterval)
+	}
+	stopping := make(chan struct{})
+	go func() {
+		<-sigChan
+		log.Println("Received shutdown signal, finishing current batch before shutting down (send again to force quit)...")
+		close(stopping)
+		<-sigChan
+		log.Println("Received second shutdown signal, forcing shutdown...")
+		cancel()
+	}()
+
+	for ts.running {
+		// Stop before starting another cycle once shutdown was requested
+		select {
+		case <-stopping:
+			ts.running = false
+			if runCtx.Err() != nil {
+				return errors.New("shutdown forced by second signal")
+			}
+			log.Println("Shutting down gracefully...")
+			return nil
+		default:
+		}
+
+		select {
+		case <-stopping:
+			continue
+
+		case sig := <-ctlChan:
+			if sig == pauseSignal {
+				ts.paused = !ts.paused
+			} else {
+				ts.paused = false
+			}
+			if ts.paused {
+				log.Printf("⏸️ Received %v, processing paused", sig)
+			} else {
+				log.Printf("▶️ Received %v, processing resumed", sig)
+			}
+
+		case <-ticker.C:
+			if ts.isPaused() {
+				log.Printf("⏸️ Processing paused, skipping cycle")
+				lastActive = time.Now()
+				continue
+			}
+
+			if ts.usage().exhausted() {
+				log.Printf("💸 Spend budget of $%.2f reached (estimated $%.4f), processing paused",
+					ts.usage().maxCost, ts.usage().cost())
+				continue
+			}
+
+			processed, err := ts.ProcessPendingTranslations(runCtx)
+			if streak := ts.consecutiveAPIErrors(); ts.maxConsecutiveErrors > 0 && streak >= int64(ts.maxConsecutiveErrors) {
+				// Fail fast so an orchestrator restarts the service
+				return fmt.Errorf("%d consecutive API errors, giving up", streak)
+			}
+			if err != nil {
+				log.Printf("Error processing pending translations: %v", err)
+				lastActive = time.Now()
+				continue
+			}
+
+			if processed > 0 {
+				lastActive = time.Now()
+				log.Printf("Processed %d items in this cycle", processed)
+				// Show updated stats
+				err = ts.ShowStats(runCtx, ts.statsFull)
+				if err != nil {
+					log.Printf("Error showing stats: %v", err)
+				}
+			} else {
+				now := time.Now().Format("15:04:05")
+				log.Printf("[%s] No pending translations found", now)
+
+				if ts.idleExitAfter > 0 && time.Since(lastActive) >= ts.idleExitAfter {
+					log.Printf("Idle for %s, exiting", time.Since(lastActive).Round(time.Second))
+					ts.running = false
+					return nil
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// consecutiveAPIErrors returns the translator's failed API call streak, 0
+// when it doesn't count them
+func (ts *TranslationService) consecutiveAPIErrors() int64 {
+	if tracking, ok := ts.translator.(errorStreakTracking); ok {
+		return tracking.ConsecutiveErrors()
+	}
+	return 0
+}
+
+// isPaused reports whether processing is paused by signal or pause file
+func (ts *TranslationService) isPaused() bool {
+	if ts.paused {
+		return true
+	}
+	if ts.pauseFile != "" {
+		if _, err := os.Stat(ts.pauseFile); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// RunOnce processes a single batch of pending translations, or the whole
+// queue when draining, and returns
+func (ts *TranslationService) RunOnce(ctx context.Context) error {
+	err := ts.ConnectMongoDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to MongoDB: %w", err)
+	}
+	defer ts.CloseMongoDB(ctx)
+
+	var processed int
+	if ts.drain {
+		processed, err = ts.DrainPending(ctx)
+	} else {
+		processed, err = ts.ProcessPendingTranslations(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("error processing pending translations: %w", err)
+	}
+	log.Printf("Processed %d items", processed)
+
+	err = ts.ShowStats(ctx, ts.statsFull)
+	if err != nil {
+		log.Printf("Error showing stats: %v", err)
+	}
+
+	if ts.usage().exhausted() {
+		log.Printf("💸 Spend budget of $%.2f reached (estimated $%.4f), stopped issuing API calls",
+			ts.usage().maxCost, ts.usage().cost())
+	}
+	return nil
+}
+
+// encodeMongoURI properly encodes MongoDB URI with special characters
+func encodeMongoURI(uri string) string {
+	// If URI doesn't contain authentication, return as is
+	if !strings.Contains(uri, "@") {
+		return uri
+	}
+
+	// Split the URI into parts
+	parts := strings.SplitN(uri, "://", 2)
+	if len(parts) != 2 {
+		return uri
+	}
+
+	scheme := parts[0]
+	rest := parts[1]
+
+	// Split userinfo and host parts
+	userinfoParts := strings.SplitN(rest, "@", 2)
+	if len(userinfoParts) != 2 {
+		return uri
+	}
+
+	userinfo := userinfoParts[0]
+	hostPart := userinfoParts[1]
+
+	// Split username and password
+	authParts := strings.SplitN(userinfo, ":", 2)
+	if len(authParts) != 2 {
+		return uri
+	}
+
+	username := authParts[0]
+	password := authParts[1]
+
+	// Encode username and password using proper URL encoding
+	encodedUsername := url.QueryEscape(username)
+	encodedPassword := url.QueryEscape(password)
+
+	// Reconstruct the URI
+	encodedUserinfo := encodedUsername + ":" + encodedPassword
+	return scheme + "://" + encodedUserinfo + "@" + hostPart
+}
@@ -0,0 +1,409 @@
+package translation
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestCommittedOperations(t *testing.T) {
+	ops := []UpdateOperation{{ProductHash: "a"}, {ProductHash: "b"}, {ProductHash: "c"}, {ProductHash: "d"}}
+	writeErrors := func(indices ...int) mongo.BulkWriteException {
+		var bulkErr mongo.BulkWriteException
+		for _, index := range indices {
+			bulkErr.WriteErrors = append(bulkErr.WriteErrors, mongo.BulkWriteError{WriteError: mongo.WriteError{Index: index}})
+		}
+		return bulkErr
+	}
+
+	tests := []struct {
+		name    string
+		bulkErr mongo.BulkWriteException
+		ordered bool
+		want    []string
+	}{
+		{"ordered stops at the first failure", writeErrors(1), true, []string{"a"}},
+		{"unordered skips only the failures", writeErrors(1, 3), false, []string{"a", "c"}},
+		{"ordered failure of the first operation", writeErrors(0), true, nil},
+		{"unordered errors out of order", writeErrors(3, 0), false, []string{"b", "c"}},
+		{"no write errors", writeErrors(), true, []string{"a", "b", "c", "d"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, op := range committedOperations(ops, tt.bulkErr, tt.ordered) {
+				got = append(got, op.ProductHash)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("committedOperations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPendingFilter(t *testing.T) {
+	tests := []struct {
+		disposition string
+		want        bson.M
+	}{
+		{"delete", bson.M{}},
+		{"archive", bson.M{}},
+		{"mark", bson.M{"status": bson.M{"$ne": "done"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.disposition, func(t *testing.T) {
+			ts := &TranslationService{pendingDisposition: tt.disposition}
+			if got := ts.pendingFilter(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pendingFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslateTruncatedResponseSplits(t *testing.T) {
+	dt, api := newFakeAPI(t, func(call int, texts []string) (int, string, string) {
+		if len(texts) > 2 {
+			// Cut off after the first item
+			return http.StatusOK, "1. 译:" + texts[0], "length"
+		}
+		return http.StatusOK, numberedAnswer(texts), "stop"
+	})
+
+	texts := []string{"赤", "青", "黄", "緑"}
+	got, err := dt.TranslateFieldTexts(context.Background(), "name", texts)
+	if err != nil {
+		t.Fatalf("TranslateFieldTexts: %v", err)
+	}
+	want := []string{"译:赤", "译:青", "译:黄", "译:緑"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("translations = %v, want %v", got, want)
+	}
+	if n := api.callCount(); n != 3 {
+		t.Errorf("API calls = %d, want the truncated call and two halves", n)
+	}
+}
+
+func TestTranslateTruncatedSingleText(t *testing.T) {
+	dt, _ := newFakeAPI(t, func(call int, texts []string) (int, string, string) {
+		return http.StatusOK, "1. 译:", "length"
+	})
+
+	_, err := dt.TranslateFieldTexts(context.Background(), "name", []string{"赤"})
+	if !errors.Is(err, ErrTruncated) {
+		t.Errorf("err = %v, want ErrTruncated", err)
+	}
+}
+
+func TestTranslateRetriesFailedCalls(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxRetries int
+		failures   int // calls failing before the API recovers
+		status     int
+		wantErr    bool
+		wantCalls  int
+	}{
+		{"recovers within retries", 3, 2, http.StatusServiceUnavailable, false, 3},
+		{"rate limited", 1, 1, http.StatusTooManyRequests, false, 2},
+		{"retries used up", 1, 2, http.StatusServiceUnavailable, true, 2},
+		{"no retries", 0, 1, http.StatusBadGateway, true, 1},
+		{"client errors not retried", 3, 1, http.StatusBadRequest, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dt, api := newFakeAPI(t, func(call int, texts []string) (int, string, string) {
+				if call < tt.failures {
+					return tt.status, "", ""
+				}
+				return http.StatusOK, numberedAnswer(texts), "stop"
+			})
+			dt.maxRetries = tt.maxRetries
+
+			got, err := dt.TranslateFieldTexts(context.Background(), "name", []string{"赤"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, []string{"译:赤"}) {
+				t.Errorf("translations = %v, want [译:赤]", got)
+			}
+			if n := api.callCount(); n != tt.wantCalls {
+				t.Errorf("API calls = %d, want %d", n, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestTranslateRetriesEmptyChoices(t *testing.T) {
+	dt, api := newFakeAPI(t, func(call int, texts []string) (int, string, string) {
+		if call == 0 {
+			return http.StatusOK, noChoices, ""
+		}
+		return http.StatusOK, numberedAnswer(texts), "stop"
+	})
+
+	got, err := dt.TranslateFieldTexts(context.Background(), "name", []string{"赤", "青"})
+	if err != nil {
+		t.Fatalf("TranslateFieldTexts: %v", err)
+	}
+	if want := []string{"译:赤", "译:青"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("translations = %v, want %v", got, want)
+	}
+	if n := api.callCount(); n != 2 {
+		t.Errorf("API calls = %d, want the empty answer and its retry", n)
+	}
+
+	// Without retries left the texts fail with the error
+	dt.maxRetries = 0
+	api.respond = func(call int, texts []string) (int, string, string) { return http.StatusOK, noChoices, "" }
+	if _, err := dt.TranslateFieldTexts(context.Background(), "name", []string{"赤"}); !errors.Is(err, ErrEmptyChoices) {
+		t.Errorf("err = %v, want ErrEmptyChoices", err)
+	}
+}
+
+func TestParseTranslations(t *testing.T) {
+	tests := []struct {
+		name           string
+		response       string
+		expected       int
+		want           []string
+		wantSequential bool
+	}{
+		{"numbered list", "1. 红\n---\n2. 蓝", 2, []string{"红", "蓝"}, true},
+		{"full-width and parenthesis numbering", "1） 红\n2：蓝\n3) 黄", 3, []string{"红", "蓝", "黄"}, true},
+		{"preamble and commentary dropped", "Here are the translations:\n1. 红\nNote: 红 means red\n2. 蓝\nHope this helps!", 2, []string{"红", "蓝"}, true},
+		{"gap in numbering", "1. 红\n3. 蓝", 2, []string{"红", "蓝"}, false},
+		{"repeated number", "1. 红\n1. 蓝", 2, []string{"红", "蓝"}, false},
+		{"more items than expected", "1. 红\n2. 蓝\n3. 黄", 2, []string{"红", "蓝", "黄"}, false},
+		{"missing item", "1. 红", 2, []string{"红"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dt := &DeepSeekTranslator{listStart: 1}
+			got, sequential := dt.parseTranslations(tt.response, tt.expected)
+			if !reflect.DeepEqual(got, tt.want) || sequential != tt.wantSequential {
+				t.Errorf("parseTranslations() = %v, %v, want %v, %v", got, sequential, tt.want, tt.wantSequential)
+			}
+		})
+	}
+}
+
+func TestTranslateMisnumberedResponseSplits(t *testing.T) {
+	dt, api := newFakeAPI(t, func(call int, texts []string) (int, string, string) {
+		if len(texts) > 2 {
+			return http.StatusOK, "1. 译:" + texts[0] + "\n3. 译:" + texts[2], "stop"
+		}
+		return http.StatusOK, numberedAnswer(texts), "stop"
+	})
+
+	got, err := dt.TranslateFieldTexts(context.Background(), "name", []string{"赤", "青", "黄", "緑"})
+	if err != nil {
+		t.Fatalf("TranslateFieldTexts: %v", err)
+	}
+	if want := []string{"译:赤", "译:青", "译:黄", "译:緑"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("translations = %v, want %v", got, want)
+	}
+	if n := api.callCount(); n != 3 {
+		t.Errorf("API calls = %d, want the misnumbered call and two halves", n)
+	}
+}
+
+func TestParseTranslationsListStart(t *testing.T) {
+	tests := []struct {
+		listStart      int
+		response       string
+		wantSequential bool
+	}{
+		{0, "0. 红\n1. 蓝", true},
+		{0, "1. 红\n2. 蓝", false},
+		{1, "0. 红\n1. 蓝", false},
+	}
+	for _, tt := range tests {
+		dt := &DeepSeekTranslator{listStart: tt.listStart}
+		got, sequential := dt.parseTranslations(tt.response, 2)
+		if want := []string{"红", "蓝"}; !reflect.DeepEqual(got, want) || sequential != tt.wantSequential {
+			t.Errorf("listStart %d: parseTranslations(%q) = %v, %v, want %v, %v",
+				tt.listStart, tt.response, got, sequential, want, tt.wantSequential)
+		}
+	}
+}
+
+func TestSkipTranslatedFields(t *testing.T) {
+	ts := newTestService(fakeTranslator{},
+		WithFieldConfig("maker", FieldConfig{Target: "makerZH"}),
+		WithFieldConfig("localizedName.ja", FieldConfig{Target: "localizedName.zh"}))
+	items := []PendingItem{
+		{ProductHash: "a", Name: "ガンダム", Description: "新作", Extra: bson.M{"maker": "バンダイ"}},
+		{ProductHash: "b", Name: "ザク", Extra: bson.M{"localizedName": bson.M{"ja": "ザク"}}},
+		{ProductHash: "c", Name: "グフ"},
+	}
+	existing := map[string]NormalizedItem{
+		"a": {ProductHash: "a", NameCN: "高达", Extra: bson.M{"makerZH": "万代"}},
+		"b": {ProductHash: "b", NameCN: "扎古", Extra: bson.M{"localizedName": bson.M{"zh": "扎古"}}},
+	}
+
+	remaining, done := ts.skipTranslatedFields(items, existing)
+	if !reflect.DeepEqual(done, []string{"b"}) {
+		t.Errorf("done = %v, want [b]", done)
+	}
+	if len(remaining) != 2 {
+		t.Fatalf("remaining = %v, want a and c", remaining)
+	}
+	// The name is kept, only the description is translated
+	a := TranslatedItem{PendingItem: remaining[0]}
+	for field, want := range map[string]string{"name": "", "description": "新作", "maker": ""} {
+		if source, _ := a.fieldValues(field); source != want {
+			t.Errorf("%s source = %q, want %q", field, source, want)
+		}
+	}
+	c := TranslatedItem{PendingItem: remaining[1]}
+	if source, _ := c.fieldValues("name"); source != "グフ" {
+		t.Errorf("name of an untranslated product = %q, want グフ", source)
+	}
+}
+
+func TestLocalTranslatorWithoutKey(t *testing.T) {
+	dt, api := newFakeAPI(t, nil)
+	got, err := dt.TranslateTexts(context.Background(), []string{"ガンダム", "ザク"})
+	if err != nil {
+		t.Fatalf("TranslateTexts: %v", err)
+	}
+	if !reflect.DeepEqual(got, []string{"译:ガンダム", "译:ザク"}) {
+		t.Errorf("translations = %q", got)
+	}
+
+	dt.apiKey = "secret"
+	if _, err := dt.TranslateTexts(context.Background(), []string{"ガンダム", "ザク"}); err != nil {
+		t.Fatalf("TranslateTexts with a key: %v", err)
+	}
+	if want := []string{"", "Bearer secret"}; !reflect.DeepEqual(api.auth, want) {
+		t.Errorf("Authorization headers = %q, want %q", api.auth, want)
+	}
+}
+
+func TestLocalTranslatorResponseQuirks(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    string
+		wantErr string
+	}{
+		{"completion text", `{"choices": [{"text": "高达"}]}`, "高达", ""},
+		{"reasoning block", `{"choices": [{"message": {"content": "<think>\nガンダム is Gundam\n</think>\n高达"}}]}`, "高达", ""},
+		{"error with a 200", `{"error": {"message": "model not loaded"}}`, "", "model not loaded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, tt.body)
+			}))
+			defer server.Close()
+			t.Setenv("LOCAL_API_KEY", "")
+			dt := NewLocalTranslator(server.URL, "test-model")
+			dt.maxRetries = 0
+
+			got, err := dt.TranslateFieldTexts(context.Background(), "name", []string{"ガンダム"})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TranslateFieldTexts: %v", err)
+			}
+			if got[0] != tt.want {
+				t.Errorf("translation = %q, want %q", got[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestTickIntervalClampsZero(t *testing.T) {
+	tests := []struct {
+		interval int
+		want     time.Duration
+	}{
+		{0, time.Second},
+		{-5, time.Second},
+		{30, 30 * time.Second},
+	}
+	for _, tt := range tests {
+		ts := newTestService(fakeTranslator{})
+		ts.checkInterval = tt.interval
+		if got := ts.tickInterval(); got != tt.want {
+			t.Errorf("interval %d: tickInterval() = %v, want %v", tt.interval, got, tt.want)
+		}
+	}
+}
+
+func TestCacheTranslationSkipsOversizedEntry(t *testing.T) {
+	ts := newTestService(fakeTranslator{})
+	ts.cacheMaxEntryBytes = 1024
+	ts.memoryCache = newLRUCache(10)
+
+	// The service has no cache collection: a write would panic
+	long := strings.Repeat("説明", 200)
+	if err := ts.CacheTranslation(context.Background(), "description", long, "译:"+long); err != nil {
+		t.Fatalf("CacheTranslation: %v", err)
+	}
+	if _, ok := ts.memoryCache.Get(ts.memoryCacheKey("description", ts.GetTextHash(long))); ok {
+		t.Error("the oversized entry was cached in memory")
+	}
+}
+
+func TestWhitespaceOnlyBatchSkipsAPI(t *testing.T) {
+	dt, api := newFakeAPI(t, nil)
+	texts := []string{"", "  ", "\n\t"}
+	got, err := dt.TranslateFieldTexts(context.Background(), "name", texts)
+	if err != nil {
+		t.Fatalf("TranslateFieldTexts: %v", err)
+	}
+	if !reflect.DeepEqual(got, texts) {
+		t.Errorf("translations = %q, want the blank texts as they were", got)
+	}
+	if n := api.callCount(); n != 0 {
+		t.Errorf("API calls = %d, want none", n)
+	}
+
+	got, err = dt.TranslateFieldTexts(context.Background(), "name", []string{" ", "赤"})
+	if err != nil {
+		t.Fatalf("TranslateFieldTexts: %v", err)
+	}
+	if !reflect.DeepEqual(got, []string{" ", "译:赤"}) || !reflect.DeepEqual(api.calls[0], []string{"赤"}) {
+		t.Errorf("translations = %q after sending %q, want only 赤 sent", got, api.calls[0])
+	}
+}
+
+func TestSingleTextWithoutNumbering(t *testing.T) {
+	tests := []struct {
+		name   string
+		answer string
+	}{
+		{"no numbering", "高达模型"},
+		{"surrounding whitespace", "\n 高达模型 \n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dt, _ := newFakeAPI(t, func(call int, texts []string) (int, string, string) {
+				return http.StatusOK, tt.answer, "stop"
+			})
+			dt.plainSingleText = true
+			got, err := dt.TranslateTexts(context.Background(), []string{"ガンプラ"})
+			if err != nil {
+				t.Fatalf("TranslateTexts: %v", err)
+			}
+			if got[0] != "高达模型" {
+				t.Errorf("translation = %q, want 高达模型", got[0])
+			}
+		})
+	}
+}
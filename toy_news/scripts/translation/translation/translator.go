@@ -0,0 +1,119 @@
+package translation
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Translator translates batches of texts for the service. Implementations
+// return one translation per input text; see DeepSeekTranslator for the
+// error contract.
+type Translator interface {
+	TranslateFieldTexts(ctx context.Context, field string, texts []string) ([]string, error)
+	Provenance(field string) Provenance
+}
+
+// usageTracking is implemented by translators that track their API spend
+type usageTracking interface {
+	UsageTracker() *usageTracker
+}
+
+// errorStreakTracking is implemented by translators that count their
+// consecutive failed API calls
+type errorStreakTracking interface {
+	ConsecutiveErrors() int64
+}
+
+// apiCallCounting is implemented by translators that count the API calls
+// they made
+type apiCallCounting interface {
+	APICalls() int64
+}
+
+// throttleRating is implemented by translators spacing their API calls by an
+// adaptive throttle
+type throttleRating interface {
+	ThrottleRate() float64
+}
+
+// isCodeModel reports whether a model name looks like a code model, such as
+// deepseek-coder, which produces poor translations
+func isCodeModel(model string) bool {
+	model = strings.ToLower(model)
+	return strings.Contains(model, "coder") || strings.Contains(model, "codestral") || strings.Contains(model, "codellama")
+}
+
+// newTranslator creates the translator of a provider. Empty apiBase and model
+// select the provider's defaults (deepseek-chat for DeepSeek), and a
+// code-oriented model is accepted with a warning.
+func newTranslator(provider, apiBase, model string) (Translator, error) {
+	if isCodeModel(model) {
+		log.Printf("⚠️  模型 %s 是代码模型，翻译质量可能较差；建议使用 deepseek-chat 等对话模型", model)
+	}
+	apiBase, model, err := providerDefaults(provider, apiBase, model)
+	if err != nil {
+		return nil, err
+	}
+	switch provider {
+	case "deepseek":
+		dt := NewDeepSeekTranslator()
+		dt.baseURL = strings.TrimRight(apiBase, "/")
+		dt.model = model
+		return dt, nil
+	case "local":
+		return NewLocalTranslator(apiBase, model), nil
+	}
+	return StubTranslator{}, nil
+}
+
+// Provider defaults for an empty -api-base or -model
+const (
+	defaultDeepSeekBase  = "https://api.deepseek.com"
+	defaultDeepSeekModel = "deepseek-chat"
+	defaultLocalBase     = "http://localhost:11434/v1"
+)
+
+// providerDefaults returns the API base and model of a provider, filling in
+// its defaults for empty ones. The local provider has no default model.
+func providerDefaults(provider, apiBase, model string) (string, string, error) {
+	switch provider {
+	case "deepseek":
+		if apiBase == "" {
+			apiBase = defaultDeepSeekBase
+		}
+		if model == "" {
+			model = defaultDeepSeekModel
+		}
+	case "local":
+		if apiBase == "" {
+			apiBase = defaultLocalBase
+		}
+		if model == "" {
+			return "", "", fmt.Errorf("a model is required for the local provider")
+		}
+	case "stub":
+	default:
+		return "", "", fmt.Errorf("unknown provider %q (expected deepseek, local or stub)", provider)
+	}
+	return apiBase, model, nil
+}
+
+// StubTranslator is an offline translator for tests and local demos. It
+// returns a deterministic transformation of every text without calling an API.
+type StubTranslator struct{}
+
+// TranslateFieldTexts prefixes every text with "[translated] "
+func (StubTranslator) TranslateFieldTexts(ctx context.Context, field string, texts []string) ([]string, error) {
+	translations := make([]string, len(texts))
+	for i, text := range texts {
+		translations[i] = "[translated] " + text
+	}
+	return translations, nil
+}
+
+// Provenance identifies stub translations so they are never mistaken for real ones
+func (StubTranslator) Provenance(field string) Provenance {
+	return Provenance{Provider: "stub", Model: "stub"}
+}
@@ -0,0 +1,43 @@
+package translation
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDefaultModelIsChatModel(t *testing.T) {
+	t.Setenv("DEEPSEEK_API_KEY", "test-key")
+	if dt := NewDeepSeekTranslator(); dt.model != "deepseek-chat" || isCodeModel(dt.model) {
+		t.Errorf("default model = %q, want deepseek-chat", dt.model)
+	}
+}
+
+func TestCodeModelWarning(t *testing.T) {
+	t.Setenv("DEEPSEEK_API_KEY", "test-key")
+	tests := []struct {
+		model    string
+		wantWarn bool
+	}{
+		{"deepseek-coder", true},
+		{"DeepSeek-Coder-V2", true},
+		{"codellama:13b", true},
+		{"deepseek-chat", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			var buf bytes.Buffer
+			log.SetOutput(&buf)
+			defer log.SetOutput(os.Stderr)
+			if _, err := newTranslator("deepseek", "", tt.model); err != nil {
+				t.Fatalf("newTranslator: %v", err)
+			}
+			if warned := strings.Contains(buf.String(), "代码模型"); warned != tt.wantWarn {
+				t.Errorf("warned %v for %q, want %v", warned, tt.model, tt.wantWarn)
+			}
+		})
+	}
+}
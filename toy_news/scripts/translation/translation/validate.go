@@ -0,0 +1,86 @@
+package translation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// validationText is translated to validate providers without a models list
+const validationText = "こんにちは"
+
+// modelList is the response of the OpenAI-compatible /models endpoint
+type modelList struct {
+	Data []struct {
+		ID string `json:"id"`
+	} `json:"data"`
+}
+
+// ValidateProvider confirms that the API key is accepted and the configured
+// model is available. Providers listing their models at /models are checked
+// against that list; the others, and replayed runs, have to translate a
+// trivial text instead.
+func (dt *DeepSeekTranslator) ValidateProvider(ctx context.Context) error {
+	if dt.tape != nil && dt.tape.replaying() {
+		return dt.validateByTranslating(ctx)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", dt.baseURL+"/models", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	if dt.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+dt.apiKey)
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return &APIError{Err: fmt.Errorf("failed to reach %s: %w", dt.baseURL, err)}
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return &APIError{StatusCode: resp.StatusCode, Err: fmt.Errorf("failed to read response body: %w", err)}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return &APIError{StatusCode: resp.StatusCode, Err: fmt.Errorf("API key rejected: %s", string(body))}
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		log.Printf("ℹ️  %s 没有模型列表接口，改为试译验证", dt.baseURL)
+		return dt.validateByTranslating(ctx)
+	default:
+		return &APIError{StatusCode: resp.StatusCode, Err: fmt.Errorf("listing models failed: %s", string(body))}
+	}
+
+	var models modelList
+	if err := json.Unmarshal(body, &models); err != nil {
+		log.Printf("ℹ️  无法解析模型列表 (%v)，改为试译验证", err)
+		return dt.validateByTranslating(ctx)
+	}
+	var ids []string
+	for _, model := range models.Data {
+		if model.ID == dt.model {
+			return nil
+		}
+		ids = append(ids, model.ID)
+	}
+	sort.Strings(ids)
+	return fmt.Errorf("model %s is not available (available: %v)", dt.model, ids)
+}
+
+// validateByTranslating validates the provider by translating validationText
+func (dt *DeepSeekTranslator) validateByTranslating(ctx context.Context) error {
+	translations, err := dt.TranslateTexts(ctx, []string{validationText})
+	if err != nil {
+		return fmt.Errorf("trial translation failed: %w", err)
+	}
+	log.Printf("✅ 试译: %s -> %s", validationText, translations[0])
+	return nil
+}
@@ -0,0 +1,37 @@
+package translation
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestValidateProvider(t *testing.T) {
+	tests := []struct {
+		name      string
+		models    []string
+		wantErr   string
+		wantCalls int // trial translations
+	}{
+		{"model listed", []string{"other-model", "test-model"}, "", 0},
+		{"model missing", []string{"other-model"}, "not available", 0},
+		{"no models endpoint", nil, "", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dt, api := newFakeAPI(t, nil)
+			api.models = tt.models
+
+			err := dt.ValidateProvider(context.Background())
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("ValidateProvider: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("err = %v, want it to mention %q", err, tt.wantErr)
+			}
+			if got := api.callCount(); got != tt.wantCalls {
+				t.Errorf("%d trial translations, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
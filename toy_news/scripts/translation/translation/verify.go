@@ -0,0 +1,83 @@
+package translation
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// verifyCommitted re-reads the products of committed ops and checks that every
+// translated target field is present. Items whose translations didn't
+// persist are dead-lettered instead of leaving the queue as done; the
+// operations that persisted are returned.
+func (ts *TranslationService) verifyCommitted(ctx context.Context, ops []UpdateOperation) ([]UpdateOperation, error) {
+	if len(ops) == 0 {
+		return ops, nil
+	}
+
+	var hashes []string
+	projection := bson.M{"product_hash": 1}
+	for _, op := range ops {
+		hashes = append(hashes, op.ProductHash)
+	}
+	for _, field := range ts.fieldsToTranslate {
+		projection[ts.targetKey(field)] = 1
+	}
+
+	filter := bson.M{"product_hash": bson.M{"$in": hashes}}
+	cursor, err := ts.normalizedCollection.Find(ctx, filter, options.Find().SetProjection(projection))
+	if err != nil {
+		return nil, fmt.Errorf("error verifying written products: %w", err)
+	}
+	var docs []bson.M
+	err = cursor.All(ctx, &docs)
+	if err != nil {
+		return nil, fmt.Errorf("error verifying written products: %w", err)
+	}
+	stored := make(map[string]bson.M, len(docs))
+	for _, doc := range docs {
+		if hash, ok := doc["product_hash"].(string); ok {
+			stored[hash] = doc
+		}
+	}
+
+	var kept []UpdateOperation
+	var lost []string
+	for _, op := range ops {
+		if ts.persisted(op, stored[op.ProductHash]) {
+			kept = append(kept, op)
+			continue
+		}
+		log.Printf("⚠️  %s 的翻译写入后读取不到", op.ProductHash)
+		lost = append(lost, op.ProductHash)
+	}
+	if len(lost) == 0 {
+		return ops, nil
+	}
+
+	_, err = ts.deadLetter(ctx, bson.M{"product_hash": bson.M{"$in": lost}}, "translation not persisted")
+	if err != nil {
+		return nil, err
+	}
+	return kept, nil
+}
+
+// persisted reports whether doc carries a value for every target field op wrote
+func (ts *TranslationService) persisted(op UpdateOperation, doc bson.M) bool {
+	if doc == nil {
+		return false
+	}
+	for _, field := range ts.fieldsToTranslate {
+		target := ts.targetKey(field)
+		if _, written := op.Updates[target]; !written {
+			continue
+		}
+		if lookupPath(doc, target) == "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,58 @@
+package translation
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// writeSemaphore bounds the MongoDB bulk writes in flight across everything
+// sharing it: the batches, the cache eviction and the services of all
+// pipelines. A nil semaphore doesn't bound them.
+type writeSemaphore chan struct{}
+
+// newWriteSemaphore creates a semaphore allowing max concurrent writes, or
+// nil for no limit when max is 0
+func newWriteSemaphore(max int) writeSemaphore {
+	if max <= 0 {
+		return nil
+	}
+	return make(writeSemaphore, max)
+}
+
+// limitWrite runs write once a write slot is free, or fails when ctx is done
+// first
+func (ts *TranslationService) limitWrite(ctx context.Context, write func() error) error {
+	if ts.writeSlots == nil {
+		return write()
+	}
+	select {
+	case ts.writeSlots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-ts.writeSlots }()
+	return write()
+}
+
+// deleteMany runs a DeleteMany on collection within the write limit
+func (ts *TranslationService) deleteMany(ctx context.Context, collection *mongo.Collection, filter interface{}) (*mongo.DeleteResult, error) {
+	var result *mongo.DeleteResult
+	err := ts.limitWrite(ctx, func() error {
+		var err error
+		result, err = collection.DeleteMany(ctx, filter)
+		return err
+	})
+	return result, err
+}
+
+// updateMany runs an UpdateMany on collection within the write limit
+func (ts *TranslationService) updateMany(ctx context.Context, collection *mongo.Collection, filter, update interface{}) (*mongo.UpdateResult, error) {
+	var result *mongo.UpdateResult
+	err := ts.limitWrite(ctx, func() error {
+		var err error
+		result, err = collection.UpdateMany(ctx, filter, update)
+		return err
+	})
+	return result, err
+}
@@ -0,0 +1,70 @@
+package translation
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimitWriteBoundsConcurrency(t *testing.T) {
+	tests := []struct {
+		name      string
+		max       int
+		wantBound int64
+	}{
+		{"bounded", 2, 2},
+		{"one at a time", 1, 1},
+		{"unbounded", 0, 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Two services share the semaphore, like the pipelines do
+			slots := newWriteSemaphore(tt.max)
+			services := []*TranslationService{{writeSlots: slots}, {writeSlots: slots}}
+
+			var inFlight, peak atomic.Int64
+			start := make(chan struct{})
+			var wg sync.WaitGroup
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func(ts *TranslationService) {
+					defer wg.Done()
+					<-start
+					ts.limitWrite(context.Background(), func() error {
+						n := inFlight.Add(1)
+						for {
+							p := peak.Load()
+							if n <= p || peak.CompareAndSwap(p, n) {
+								break
+							}
+						}
+						time.Sleep(10 * time.Millisecond)
+						inFlight.Add(-1)
+						return nil
+					})
+				}(services[i%2])
+			}
+			close(start)
+			wg.Wait()
+			if got := peak.Load(); got > tt.wantBound || (tt.max > 0 && got < tt.wantBound) {
+				t.Errorf("peak concurrent writes = %d, want %d", got, tt.wantBound)
+			}
+		})
+	}
+}
+
+func TestLimitWriteCancelledWhileWaiting(t *testing.T) {
+	ts := &TranslationService{writeSlots: newWriteSemaphore(1)}
+	ts.writeSlots <- struct{}{} // another write holds the only slot
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	called := false
+	err := ts.limitWrite(ctx, func() error { called = true; return nil })
+	if !errors.Is(err, context.DeadlineExceeded) || called {
+		t.Errorf("err = %v, write called %v, want the deadline without writing", err, called)
+	}
+}